@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chenzhiwei/certctl/pkg/cert"
+)
+
+var (
+	csrSize        int
+	csrKeyType     string
+	csrCurve       string
+	csrIsCA        bool
+	csrSan         string
+	csrSubject     string
+	csrKeyUsage    string
+	csrExtKeyUsage string
+	csrKeyfile     string
+	csrCSRfile     string
+	csrKeyPassFile string
+	csrKeyPassEnv  string
+	csrKeyKDF      string
+	csrOutform     string
+
+	csrLong string = `Generate private key and certificate signing request(CSR).
+
+Examples:
+  # Generate private key and certificate signing request
+  certctl csr --subject "C=CN/ST=Beijing/L=Haidian/O=Any Corp/CN=any.com" \
+      --san "any.com,*.any.com,localhost,127.0.0.1" \
+      --key any.com.key --csr any.com.csr
+
+  # Request Key Usages and Extended Key usages with ECDSA private key
+  certctl csr --subject "C=CN/ST=Beijing/L=Haidian/O=Any Corp/CN=any.com" \
+      --san "any.com,*.any.com" \
+      --ku digitalSignature --eku serverAuth,clientAuth \
+      --key any.com.key --csr any.com.csr \
+      --key-type ecdsa --curve P-256
+
+The list of key usages are:
+  * digitalSignature
+  * contentCommitment
+  * keyEncipherment
+  * dataEncipherment
+  * keyAgreement
+  * keyCertSign
+  * cRLSign
+  * encipherOnly
+  * decipherOnly
+
+The list of extended key usages are:
+  * any
+  * serverAuth
+  * clientAuth
+  * codeSigning
+  * emailProtection
+  * IPSECEndSystem
+  * IPSECTunnel
+  * IPSECUser
+  * timeStamping
+  * OCSPSigning
+  * netscapeServerGatedCrypto
+  * microsoftServerGatedCrypto
+  * microsoftCommercialCodeSigning
+  * microsoftKernelCodeSigning
+`
+
+	csrCmd = &cobra.Command{
+		Use:     "csr",
+		Aliases: []string{"req", "request"},
+		Short:   "Generate certificate signing request",
+		Long:    csrLong,
+		Args:    cobra.MaximumNArgs(0),
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := runCSR(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	csrCmd.Flags().BoolVar(&csrIsCA, "is-ca", false, "request a CA certificate or not(Immediate CA)")
+	csrCmd.Flags().StringVar(&csrSubject, "subject", "", "the certificate subject")
+	csrCmd.Flags().StringVar(&csrSan, "san", "", "the certificate subject alternate names")
+	csrCmd.Flags().StringVar(&csrKeyUsage, "ku", "", "the requested key usage")
+	csrCmd.Flags().StringVar(&csrExtKeyUsage, "eku", "", "the requested extended key usage")
+	csrCmd.Flags().StringVar(&csrKeyType, "key-type", "rsa", "the private key type: rsa, ecdsa or ed25519")
+	csrCmd.Flags().IntVar(&csrSize, "size", 2048, "the RSA private key size")
+	csrCmd.Flags().StringVar(&csrCurve, "curve", "P-256", "the ECDSA private key curve: P-256, P-384 or P-521")
+	csrCmd.Flags().StringVar(&csrKeyfile, "key", "certctl.key", "the output key file")
+	csrCmd.Flags().StringVar(&csrCSRfile, "csr", "certctl.csr", "the output certificate signing request file")
+	csrCmd.Flags().StringVar(&csrKeyPassFile, "key-passphrase-file", "", "the file contains passphrase to encrypt the private key")
+	csrCmd.Flags().StringVar(&csrKeyPassEnv, "key-passphrase-env", "", "the environment variable contains passphrase to encrypt the private key")
+	csrCmd.Flags().StringVar(&csrKeyKDF, "key-kdf", cert.KDFPBKDF2, "the key derivation function to encrypt the private key: pbkdf2 or scrypt")
+	csrCmd.Flags().StringVar(&csrOutform, "outform", cert.FormatPEM, "the output format of certificate signing request and private key: pem or der")
+
+	csrCmd.Flags().SortFlags = false
+	csrCmd.MarkFlagRequired("subject")
+}
+
+func runCSR() error {
+	if _, err := cert.GetKDF(csrKeyKDF); err != nil {
+		return err
+	}
+
+	passphrase, err := readPassphrase(csrKeyPassFile, csrKeyPassEnv)
+	if err != nil {
+		return err
+	}
+
+	key, err := newSigner("", "", nil, csrKeyType, csrSize, csrCurve)
+	if err != nil {
+		return err
+	}
+
+	certInfo, err := cert.NewCertInfo(0, csrSubject, csrSan, csrKeyUsage, csrExtKeyUsage, csrIsCA)
+	if err != nil {
+		return err
+	}
+
+	csrBytes, err := cert.NewCertRequest(certInfo, key)
+	if err != nil {
+		return err
+	}
+
+	if err := writeKey(csrKeyfile, "", key, passphrase, csrKeyKDF, csrOutform); err != nil {
+		return err
+	}
+
+	if err := writeOutput(csrCSRfile, csrBytes, csrOutform, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Writing new certificate request to '%s'\n", csrCSRfile)
+
+	return nil
+}
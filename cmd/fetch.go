@@ -1,20 +1,29 @@
 package cmd
 
 import (
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"net/url"
 	"os"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/chenzhiwei/certctl/pkg/cert"
 	"github.com/spf13/cobra"
 )
 
 var (
-	noout bool
-	file  string
+	noout        bool
+	file         string
+	fetchOutform string
+	fetchOutput  string
+	fetchFPOnly  bool
+	fetchChain   bool
+	fetchFull    bool
+	fetchOCSP    bool
+	fetchOCSPURL string
 
 	fetchCmd = &cobra.Command{
 		Use:   "fetch url",
@@ -32,9 +41,25 @@ var (
 func init() {
 	fetchCmd.Flags().BoolVar(&noout, "noout", false, "do not print the certificate info")
 	fetchCmd.Flags().StringVar(&file, "file", "", "save the certificate to a file")
+	fetchCmd.Flags().StringVar(&fetchOutform, "outform", cert.FormatPEM, "the format of saved certificate: pem, der or pkcs7")
+	fetchCmd.Flags().StringVar(&fetchOutput, "output", outputText, "the output format of certificate info: text, json or yaml")
+	fetchCmd.Flags().BoolVar(&fetchFPOnly, "fingerprint-only", false, "print only the fingerprints and SPKI pin, one line per certificate")
+	fetchCmd.Flags().BoolVar(&fetchChain, "chain", false, "print the certificates as a chain tree from leaf to root")
+	fetchCmd.Flags().BoolVar(&fetchFull, "complete-chain", false, "fetch the intermediate certificates the server does not send from the CA Issuers URLs in AIA extension")
+	fetchCmd.Flags().BoolVar(&fetchOCSP, "ocsp", false, "print the OCSP status of the certificate from the OCSP responder in AIA extension")
+	fetchCmd.Flags().StringVar(&fetchOCSPURL, "ocsp-url", "", "print the OCSP status from the OCSP responder URL instead of the one in AIA extension")
+	fetchCmd.MarkFlagsMutuallyExclusive("fingerprint-only", "chain", "ocsp")
+	fetchCmd.MarkFlagsMutuallyExclusive("fingerprint-only", "chain", "ocsp-url")
+	fetchCmd.MarkFlagsMutuallyExclusive("noout", "ocsp")
+	fetchCmd.MarkFlagsMutuallyExclusive("noout", "ocsp-url")
 }
 
 func runFetch(args []string) error {
+	output, err := getOutput(fetchOutput)
+	if err != nil {
+		return err
+	}
+
 	s := args[0]
 	if s == "" {
 		return errors.New("something went wrong")
@@ -63,27 +88,109 @@ func runFetch(args []string) error {
 		return err
 	}
 
+	// the incomplete chain is still saved and printed before the error
+	var chainErr error
+	if fetchFull {
+		certs, err := cert.ParseCerts(certBytes)
+		if err != nil {
+			return err
+		}
+		var chain []*x509.Certificate
+		chain, chainErr = cert.CompleteChain(certs, &cert.VerifyOptions{SystemRoots: true})
+		certBytes = cert.EncodeCerts(chain)
+	}
+
 	if file != "" {
-		if err := os.WriteFile(file, certBytes, 0644); err != nil {
+		if err := writeOutput(file, certBytes, fetchOutform, 0644); err != nil {
 			return err
 		}
 	}
 
 	if !noout {
-		result, err := cert.GetCertInfo(certBytes)
+		if err := printFetched(certBytes, output); err != nil {
+			return err
+		}
+	}
+
+	return chainErr
+}
+
+// printFetched prints the fetched certificates in the format of the flags
+func printFetched(certBytes []byte, output string) error {
+	if fetchFPOnly {
+		return printFingerprints(certBytes)
+	}
+
+	if fetchChain {
+		return printChain(certBytes, output)
+	}
+
+	if fetchOCSP || fetchOCSPURL != "" {
+		return printOCSP(certBytes, fetchOCSPURL, output)
+	}
+
+	if output != outputText {
+		infos, err := cert.GetCertificateInfos(certBytes)
 		if err != nil {
 			return err
 		}
+		return printOutput(output, infos)
+	}
+
+	result, err := cert.GetCertInfo(certBytes)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+	for _, info := range result {
+		for k, v := range info {
+			fmt.Fprintf(writer, "%s\t%s\n", k, v)
+		}
+	}
 
+	writer.Flush()
+
+	return nil
+}
+
+// printOCSP prints the OCSP status of the leaf certificate, the issuer must
+// be one of the certificates
+func printOCSP(certBytes []byte, url, output string) error {
+	certs, err := cert.ParseCerts(certBytes)
+	if err != nil {
+		return err
+	}
+
+	var issuer *x509.Certificate
+	for _, c := range certs[1:] {
+		if cert.IsIssuedBy(certs[0], c) {
+			issuer = c
+			break
+		}
+	}
+	if issuer == nil {
+		return fmt.Errorf("unable to check OCSP status, the issuer %s is not found", certs[0].Issuer.String())
+	}
+
+	check := cert.CheckOCSP(certs[0], issuer, url, time.Time{})
+	if output != outputText {
+		if err := printOutput(output, check); err != nil {
+			return err
+		}
+	} else {
 		writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
-		for _, info := range result {
+		for _, info := range cert.GetOCSPInfo(check) {
 			for k, v := range info {
 				fmt.Fprintf(writer, "%s\t%s\n", k, v)
 			}
 		}
-
 		writer.Flush()
 	}
 
+	if !check.Verified {
+		return fmt.Errorf("unable to verify OCSP status")
+	}
+
 	return nil
 }
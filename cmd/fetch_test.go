@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFetchNooutOCSP(t *testing.T) {
+	// the flags are rejected before connecting to the server
+	var tests = [][]string{
+		{"--noout", "--ocsp"},
+		{"--noout", "--ocsp-url", "http://127.0.0.1/ocsp"},
+	}
+
+	resetFlags := func() {
+		for _, name := range []string{"noout", "ocsp", "ocsp-url"} {
+			f := fetchCmd.Flags().Lookup(name)
+			f.Value.Set(f.DefValue)
+			f.Changed = false
+		}
+	}
+	defer resetFlags()
+
+	rootCmd.SetErr(io.Discard)
+	defer rootCmd.SetErr(nil)
+
+	for _, flags := range tests {
+		resetFlags()
+		rootCmd.SetArgs(append([]string{"fetch", "127.0.0.1:1"}, flags...))
+		err := rootCmd.Execute()
+		if err == nil || !strings.Contains(err.Error(), "none of the others can be") {
+			t.Errorf("failed fetch %s:\n\tactual: %v\n\texpect: mutually exclusive flags\n", strings.Join(flags, " "), err)
+		}
+	}
+}
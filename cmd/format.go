@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/chenzhiwei/certctl/pkg/cert"
+)
+
+// readInput reads the file in inform and converts it to PEM, the blockType
+// is the PEM block type of DER data, the inform is detected if it is empty
+func readInput(file, inform, blockType string) ([]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return cert.ToPEM(data, inform, blockType)
+}
+
+// writeOutput converts the PEM data to outform and writes it to file
+func writeOutput(file string, pemBytes []byte, outform string, perm os.FileMode) error {
+	data, err := cert.FromPEM(pemBytes, outform)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, data, perm)
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -12,6 +11,8 @@ import (
 
 var (
 	caSize        int
+	caKeyType     string
+	caCurve       string
 	caDays        int
 	caSubject     string
 	caSan         string
@@ -20,6 +21,11 @@ var (
 	caNoDefaults  bool
 	caKeyfile     string
 	caCertfile    string
+	caReuseKey    string
+	caKeyPassFile string
+	caKeyPassEnv  string
+	caKeyKDF      string
+	caOutform     string
 
 	gencaLong string = `Generate Root CA certificate.
 
@@ -29,6 +35,21 @@ Examples:
       --key ca.key --cert ca.crt \
       --days 36500 --size 2048
 
+  # Generate Root CA certificate with ECDSA P-384 private key
+  certctl genca --subject "C=CN/ST=Beijing/L=Haidian/O=Any Corp/CN=Root CA" \
+      --key ca.key --cert ca.crt \
+      --days 36500 --key-type ecdsa --curve P-384
+
+  # Renew Root CA certificate with the existing private key
+  certctl genca --subject "C=CN/ST=Beijing/L=Haidian/O=Any Corp/CN=Root CA" \
+      --reuse-key ca.key --cert ca.crt \
+      --days 36500
+
+  # Generate Root CA certificate with encrypted private key
+  certctl genca --subject "C=CN/ST=Beijing/L=Haidian/O=Any Corp/CN=Root CA" \
+      --key ca.key --cert ca.crt \
+      --key-passphrase-env CA_PASSPHRASE --key-kdf scrypt
+
   # Set Key Usages and Extended Key usages manaully
   certctl genca --subject "C=CN/ST=Beijing/L=Haidian/O=Any Corp/CN=Root CA" \
       --nodefault \
@@ -86,18 +107,42 @@ func init() {
 	gencaCmd.Flags().StringVar(&caKeyUsage, "ku", "", "the certificate key usage")
 	gencaCmd.Flags().StringVar(&caExtKeyUsage, "eku", "", "the certificate extended key usage")
 	gencaCmd.Flags().IntVar(&caDays, "days", 365, "the certificate validation period")
+	gencaCmd.Flags().StringVar(&caKeyType, "key-type", "rsa", "the private key type: rsa, ecdsa or ed25519")
 	gencaCmd.Flags().IntVar(&caSize, "size", 2048, "the certificate RSA private key size")
+	gencaCmd.Flags().StringVar(&caCurve, "curve", "P-256", "the ECDSA private key curve: P-256, P-384 or P-521")
 	gencaCmd.Flags().BoolVar(&caNoDefaults, "nodefault", false, "do not set any default vaules")
 	gencaCmd.Flags().StringVar(&caKeyfile, "key", "certctl.key", "the output key file")
 	gencaCmd.Flags().StringVar(&caCertfile, "cert", "certctl.crt", "the output cert file")
+	gencaCmd.Flags().StringVar(&caReuseKey, "reuse-key", "", "reuse the existing private key file instead of generating a new one")
+	gencaCmd.Flags().StringVar(&caKeyPassFile, "key-passphrase-file", "", "the file contains passphrase to encrypt or decrypt the private key")
+	gencaCmd.Flags().StringVar(&caKeyPassEnv, "key-passphrase-env", "", "the environment variable contains passphrase to encrypt or decrypt the private key")
+	gencaCmd.Flags().StringVar(&caKeyKDF, "key-kdf", cert.KDFPBKDF2, "the key derivation function to encrypt the private key: pbkdf2 or scrypt")
+	gencaCmd.Flags().StringVar(&caOutform, "outform", cert.FormatPEM, "the output format of certificate and private key: pem or der")
 
 	gencaCmd.Flags().SortFlags = false
 	gencaCmd.MarkFlagRequired("subject")
+	gencaCmd.MarkFlagsMutuallyExclusive("reuse-key", "key-type")
+	gencaCmd.MarkFlagsMutuallyExclusive("reuse-key", "size")
+	gencaCmd.MarkFlagsMutuallyExclusive("reuse-key", "curve")
 }
 
 func runGenerateCA() error {
 	duration := time.Hour * 24 * time.Duration(caDays)
 
+	if _, err := cert.GetKDF(caKeyKDF); err != nil {
+		return err
+	}
+
+	passphrase, err := readPassphrase(caKeyPassFile, caKeyPassEnv)
+	if err != nil {
+		return err
+	}
+
+	key, err := newSigner(caReuseKey, "", passphrase, caKeyType, caSize, caCurve)
+	if err != nil {
+		return err
+	}
+
 	if !caNoDefaults {
 		caKeyUsage = "cRLSign,keyCertSign,digitalSignature"
 		caExtKeyUsage = ""
@@ -108,17 +153,16 @@ func runGenerateCA() error {
 		return err
 	}
 
-	certBytes, keyBytes, err := cert.NewCertKey(certInfo, caSize)
+	certBytes, err := cert.NewCert(certInfo, key)
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(caKeyfile, keyBytes, 0600); err != nil {
+	if err := writeKey(caKeyfile, caReuseKey, key, passphrase, caKeyKDF, caOutform); err != nil {
 		return err
 	}
-	fmt.Printf("Writing new private key to '%s'\n", caKeyfile)
 
-	if err := os.WriteFile(caCertfile, certBytes, 0644); err != nil {
+	if err := writeOutput(caCertfile, certBytes, caOutform, 0644); err != nil {
 		return err
 	}
 	fmt.Printf("Writing new certificate to '%s'\n", caCertfile)
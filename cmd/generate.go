@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -12,6 +11,8 @@ import (
 
 var (
 	size        int
+	keyType     string
+	curve       string
 	days        int
 	san         string
 	subject     string
@@ -20,6 +21,11 @@ var (
 	noDefaults  bool
 	keyfile     string
 	certfile    string
+	reuseKey    string
+	keyPassFile string
+	keyPassEnv  string
+	keyKDF      string
+	outform     string
 
 	generateLong string = `Generate self-signed certificate.
 
@@ -37,6 +43,22 @@ Examples:
       --key any.com.key --cert any.com.crt \
       --days 730 --size 2048
 
+  # Generate self-signed certificate with ECDSA P-256 private key
+  certctl generate --subject "C=CN/ST=Beijing/L=Haidian/O=Any Corp/CN=any.com" \
+      --san "any.com,*.any.com,localhost,127.0.0.1" \
+      --key any.com.key --cert any.com.crt \
+      --key-type ecdsa --curve P-256
+
+  # Renew self-signed certificate with the existing private key
+  certctl generate --subject "C=CN/ST=Beijing/L=Haidian/O=Any Corp/CN=any.com" \
+      --san "any.com,*.any.com,localhost,127.0.0.1" \
+      --reuse-key any.com.key --cert any.com.crt
+
+  # Generate self-signed certificate with encrypted private key
+  certctl generate --subject "C=CN/ST=Beijing/L=Haidian/O=Any Corp/CN=any.com" \
+      --key any.com.key --cert any.com.crt \
+      --key-passphrase-file passphrase.txt
+
 The list of key usages are:
   * digitalSignature
   * contentCommitment
@@ -86,20 +108,48 @@ func init() {
 	generateCmd.Flags().StringVar(&keyUsage, "ku", "", "the certificate key usage")
 	generateCmd.Flags().StringVar(&extKeyUsage, "eku", "", "the certificate extended key usage")
 	generateCmd.Flags().IntVar(&days, "days", 365, "the certificate validation period")
+	generateCmd.Flags().StringVar(&keyType, "key-type", "rsa", "the private key type: rsa, ecdsa or ed25519")
 	generateCmd.Flags().IntVar(&size, "size", 2048, "the certificate RSA private key size")
+	generateCmd.Flags().StringVar(&curve, "curve", "P-256", "the ECDSA private key curve: P-256, P-384 or P-521")
 	generateCmd.Flags().BoolVar(&noDefaults, "nodefault", false, "do not set any default vaules")
 	generateCmd.Flags().StringVar(&keyfile, "key", "certctl.key", "the output key file")
 	generateCmd.Flags().StringVar(&certfile, "cert", "certctl.crt", "the output cert file")
+	generateCmd.Flags().StringVar(&reuseKey, "reuse-key", "", "reuse the existing private key file instead of generating a new one")
+	generateCmd.Flags().StringVar(&keyPassFile, "key-passphrase-file", "", "the file contains passphrase to encrypt or decrypt the private key")
+	generateCmd.Flags().StringVar(&keyPassEnv, "key-passphrase-env", "", "the environment variable contains passphrase to encrypt or decrypt the private key")
+	generateCmd.Flags().StringVar(&keyKDF, "key-kdf", cert.KDFPBKDF2, "the key derivation function to encrypt the private key: pbkdf2 or scrypt")
+	generateCmd.Flags().StringVar(&outform, "outform", cert.FormatPEM, "the output format of certificate and private key: pem or der")
 
 	generateCmd.Flags().SortFlags = false
 	generateCmd.MarkFlagRequired("subject")
+	generateCmd.MarkFlagsMutuallyExclusive("reuse-key", "key-type")
+	generateCmd.MarkFlagsMutuallyExclusive("reuse-key", "size")
+	generateCmd.MarkFlagsMutuallyExclusive("reuse-key", "curve")
 }
 
 func runGenerate() error {
 	duration := time.Hour * 24 * time.Duration(days)
 
+	if _, err := cert.GetKDF(keyKDF); err != nil {
+		return err
+	}
+
+	passphrase, err := readPassphrase(keyPassFile, keyPassEnv)
+	if err != nil {
+		return err
+	}
+
+	key, err := newSigner(reuseKey, "", passphrase, keyType, size, curve)
+	if err != nil {
+		return err
+	}
+
 	if !noDefaults {
 		keyUsage = "digitalSignature,keyEncipherment"
+		if cert.GetKeyType(key.Public()) != cert.KeyTypeRSA {
+			// key encipherment is for RSA keys only
+			keyUsage = "digitalSignature"
+		}
 		extKeyUsage = "serverAuth,clientAuth"
 	}
 
@@ -108,17 +158,16 @@ func runGenerate() error {
 		return err
 	}
 
-	certBytes, keyBytes, err := cert.NewCertKey(certInfo, size)
+	certBytes, err := cert.NewCert(certInfo, key)
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(keyfile, keyBytes, 0600); err != nil {
+	if err := writeKey(keyfile, reuseKey, key, passphrase, keyKDF, outform); err != nil {
 		return err
 	}
-	fmt.Printf("Writing new private key to '%s'\n", keyfile)
 
-	if err := os.WriteFile(certfile, certBytes, 0644); err != nil {
+	if err := writeOutput(certfile, certBytes, outform, 0644); err != nil {
 		return err
 	}
 	fmt.Printf("Writing new certificate to '%s'\n", certfile)
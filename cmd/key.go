@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto"
+	"fmt"
+	"os"
+
+	"github.com/chenzhiwei/certctl/pkg/cert"
+)
+
+// readPassphrase reads the passphrase from file or environment variable,
+// returns nil if neither is provided
+func readPassphrase(file, env string) ([]byte, error) {
+	if file != "" && env != "" {
+		return nil, fmt.Errorf("the passphrase file and environment variable are mutually exclusive")
+	}
+
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		// only the first line is the passphrase
+		if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+			data = data[:i]
+		}
+		if len(data) == 0 {
+			return nil, fmt.Errorf("empty passphrase in file '%s'", file)
+		}
+		return data, nil
+	}
+
+	if env != "" {
+		value, ok := os.LookupEnv(env)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("empty passphrase in environment variable '%s'", env)
+		}
+		return []byte(value), nil
+	}
+
+	return nil, nil
+}
+
+// readSigner reads the private key file which is used to sign certificate
+func readSigner(keyfile, inform string, passphrase []byte) (crypto.Signer, error) {
+	keyBytes, err := readInput(keyfile, inform, cert.PrivateKeyBlockType)
+	if err != nil {
+		return nil, err
+	}
+
+	return cert.ParseSigner(keyBytes, passphrase)
+}
+
+// newSigner reuses the private key file if provided, otherwise generates a new one
+func newSigner(reuseKeyfile, inform string, passphrase []byte, keyType string, size int, curve string) (crypto.Signer, error) {
+	if reuseKeyfile != "" {
+		return readSigner(reuseKeyfile, inform, passphrase)
+	}
+
+	keyAlg, err := cert.NewKeyAlgorithm(keyType, size, curve)
+	if err != nil {
+		return nil, err
+	}
+
+	return keyAlg.GenerateKey()
+}
+
+// writeKey writes the private key to keyfile unless it is reused, the
+// private key is encrypted if passphrase is provided. The PKCS#7 format can
+// only contain certificates, so the private key is written in PEM.
+func writeKey(keyfile, reuseKeyfile string, key crypto.Signer, passphrase []byte, kdf, outform string) error {
+	if reuseKeyfile != "" {
+		fmt.Printf("Reusing private key from '%s'\n", reuseKeyfile)
+		return nil
+	}
+
+	outform, err := cert.GetFormat(outform)
+	if err != nil {
+		return err
+	}
+	if outform == cert.FormatPKCS7 {
+		outform = cert.FormatPEM
+	}
+
+	var keyBytes []byte
+	if len(passphrase) > 0 {
+		keyBytes, err = cert.EncodeEncryptedKey(key, passphrase, kdf)
+	} else {
+		keyBytes, err = cert.EncodeKey(key)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := writeOutput(keyfile, keyBytes, outform, 0600); err != nil {
+		return err
+	}
+	fmt.Printf("Writing new private key to '%s'\n", keyfile)
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chenzhiwei/certctl/pkg/cert"
+)
+
+var (
+	ksCertfile      string
+	ksKeyfile       string
+	ksChainfile     string
+	ksCAfile        string
+	ksFile          string
+	ksAlias         string
+	ksCAAlias       string
+	ksPassFile      string
+	ksPassEnv       string
+	ksKeyPassFile   string
+	ksKeyPassEnv    string
+	ksInKeyPassFile string
+	ksInKeyPassEnv  string
+	ksInFile        string
+	ksInform        string
+
+	keystoreLong string = `Create or list Java KeyStore(JKS) file.
+
+Examples:
+  # Create keystore with private key entry and trusted CA certificate entries
+  certctl keystore create --cert any.com.crt --key any.com.key --chain ca.crt \
+      --ca ca.crt --alias any.com --out any.com.jks --password-file password.txt
+
+  # Create truststore with trusted CA certificate entries only
+  certctl keystore create --ca ca-bundle.crt --out truststore.jks --password-env STORE_PASSWORD
+
+  # List the entries of keystore
+  certctl keystore list --in any.com.jks --password-file password.txt
+`
+
+	keystoreCmd = &cobra.Command{
+		Use:     "keystore",
+		Aliases: []string{"jks"},
+		Short:   "Create or list Java KeyStore(JKS) file",
+		Long:    keystoreLong,
+	}
+
+	keystoreCreateCmd = &cobra.Command{
+		Use:   "create",
+		Short: "Create Java KeyStore with private key and trusted certificates",
+		Args:  cobra.MaximumNArgs(0),
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := runKeystoreCreate(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	keystoreListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the entries of Java KeyStore",
+		Args:  cobra.MaximumNArgs(0),
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := runKeystoreList(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	keystoreCreateCmd.Flags().StringVar(&ksCertfile, "cert", "", "the certificate file of private key entry, the extra certificates are treated as certificate chain")
+	keystoreCreateCmd.Flags().StringVar(&ksKeyfile, "key", "", "the private key file of private key entry")
+	keystoreCreateCmd.Flags().StringVar(&ksChainfile, "chain", "", "the certificate chain file of private key entry")
+	keystoreCreateCmd.Flags().StringVar(&ksCAfile, "ca", "", "the CA certificates file of trusted certificate entries")
+	keystoreCreateCmd.Flags().StringVar(&ksFile, "out", "certctl.jks", "the output keystore file")
+	keystoreCreateCmd.Flags().StringVar(&ksAlias, "alias", "certctl", "the alias of private key entry")
+	keystoreCreateCmd.Flags().StringVar(&ksCAAlias, "ca-alias", "ca", "the alias of trusted certificate entries, suffixed with number if there are multiple CA certificates")
+	keystoreCreateCmd.Flags().StringVar(&ksPassFile, "password-file", "", "the file contains keystore password")
+	keystoreCreateCmd.Flags().StringVar(&ksPassEnv, "password-env", "", "the environment variable contains keystore password")
+	keystoreCreateCmd.Flags().StringVar(&ksKeyPassFile, "key-password-file", "", "the file contains password of private key entry, default to keystore password")
+	keystoreCreateCmd.Flags().StringVar(&ksKeyPassEnv, "key-password-env", "", "the environment variable contains password of private key entry, default to keystore password")
+	keystoreCreateCmd.Flags().StringVar(&ksInKeyPassFile, "key-passphrase-file", "", "the file contains passphrase to decrypt the private key")
+	keystoreCreateCmd.Flags().StringVar(&ksInKeyPassEnv, "key-passphrase-env", "", "the environment variable contains passphrase to decrypt the private key")
+	keystoreCreateCmd.Flags().StringVar(&ksInform, "inform", "", "the input format of certificates and private key: pem, der or pkcs7, detected automatically if not provided")
+	keystoreCreateCmd.Flags().SortFlags = false
+	keystoreCreateCmd.MarkFlagsRequiredTogether("cert", "key")
+	keystoreCreateCmd.MarkFlagsOneRequired("cert", "ca")
+
+	keystoreListCmd.Flags().StringVar(&ksInFile, "in", "", "the input keystore file")
+	keystoreListCmd.Flags().StringVar(&ksPassFile, "password-file", "", "the file contains keystore password, the integrity is not verified if not provided")
+	keystoreListCmd.Flags().StringVar(&ksPassEnv, "password-env", "", "the environment variable contains keystore password, the integrity is not verified if not provided")
+	keystoreListCmd.Flags().StringVar(&ksKeyPassFile, "key-password-file", "", "the file contains password of private key entries, default to keystore password")
+	keystoreListCmd.Flags().StringVar(&ksKeyPassEnv, "key-password-env", "", "the environment variable contains password of private key entries, default to keystore password")
+	keystoreListCmd.Flags().SortFlags = false
+	keystoreListCmd.MarkFlagRequired("in")
+
+	keystoreCmd.AddCommand(keystoreCreateCmd)
+	keystoreCmd.AddCommand(keystoreListCmd)
+}
+
+func runKeystoreCreate() error {
+	password, err := readPassphrase(ksPassFile, ksPassEnv)
+	if err != nil {
+		return err
+	}
+	if len(password) == 0 {
+		return fmt.Errorf("The keystore password is required, set --password-file or --password-env")
+	}
+
+	keyPassword, err := readPassphrase(ksKeyPassFile, ksKeyPassEnv)
+	if err != nil {
+		return err
+	}
+	if len(keyPassword) == 0 {
+		keyPassword = password
+	}
+
+	var entries []*cert.JKSEntry
+
+	if ksCertfile != "" {
+		certBytes, err := readInput(ksCertfile, ksInform, cert.CertBlockType)
+		if err != nil {
+			return err
+		}
+		certs, err := cert.ParseCerts(certBytes)
+		if err != nil {
+			return err
+		}
+
+		if ksChainfile != "" {
+			chainBytes, err := readInput(ksChainfile, ksInform, cert.CertBlockType)
+			if err != nil {
+				return err
+			}
+			chain, err := cert.ParseCerts(chainBytes)
+			if err != nil {
+				return err
+			}
+			certs = append(certs, chain...)
+		}
+
+		keyPassphrase, err := readPassphrase(ksInKeyPassFile, ksInKeyPassEnv)
+		if err != nil {
+			return err
+		}
+		key, err := readSigner(ksKeyfile, ksInform, keyPassphrase)
+		if err != nil {
+			return err
+		}
+
+		if !cert.PublicKeyEqual(certs[0].PublicKey, key.Public()) {
+			return fmt.Errorf("Failed to verify Certificate and Key: private key does not match public key")
+		}
+
+		entries = append(entries, cert.NewJKSPrivateKeyEntry(ksAlias, key, certs))
+	}
+
+	if ksCAfile != "" {
+		caBytes, err := readInput(ksCAfile, ksInform, cert.CertBlockType)
+		if err != nil {
+			return err
+		}
+		caCerts, err := cert.ParseCerts(caBytes)
+		if err != nil {
+			return err
+		}
+
+		for i, caCert := range caCerts {
+			alias := ksCAAlias
+			if len(caCerts) > 1 {
+				alias = fmt.Sprintf("%s-%d", ksCAAlias, i+1)
+			}
+			entries = append(entries, cert.NewJKSTrustedCertEntry(alias, caCert))
+		}
+	}
+
+	jksBytes, err := cert.EncodeJKS(entries, string(password), string(keyPassword))
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(ksFile, jksBytes, 0600); err != nil {
+		return err
+	}
+	fmt.Printf("Writing new keystore to '%s'\n", ksFile)
+
+	return nil
+}
+
+func runKeystoreList() error {
+	password, err := readPassphrase(ksPassFile, ksPassEnv)
+	if err != nil {
+		return err
+	}
+
+	keyPassword, err := readPassphrase(ksKeyPassFile, ksKeyPassEnv)
+	if err != nil {
+		return err
+	}
+	if len(keyPassword) == 0 {
+		keyPassword = password
+	}
+
+	jksBytes, err := os.ReadFile(ksInFile)
+	if err != nil {
+		return err
+	}
+	entries, err := cert.DecodeJKS(jksBytes, string(password))
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+	fmt.Fprintf(writer, "%s\t%s\n", "Keystore Type", "JKS")
+	fmt.Fprintf(writer, "%s\t%d\n", "Entries", len(entries))
+	if len(password) == 0 {
+		fmt.Fprintf(writer, "%s\t%s\n", "Warning", "the integrity is not verified without keystore password")
+	}
+
+	for _, entry := range entries {
+		fmt.Fprintf(writer, "\n%s\t%s\n", "Alias", entry.Alias)
+		fmt.Fprintf(writer, "%s\t%s\n", "Entry Type", entry.Type)
+		fmt.Fprintf(writer, "%s\t%s\n", "Creation Date", entry.CreationDate.String())
+
+		if entry.Type == cert.JKSPrivateKeyEntry {
+			if len(keyPassword) > 0 {
+				if err := entry.DecryptKey(string(keyPassword)); err != nil {
+					return err
+				}
+				if key, ok := entry.Key.(crypto.Signer); ok {
+					fmt.Fprintf(writer, "%s\t%s\n", "Private Key", cert.GetKeyType(key.Public()))
+				}
+			}
+			fmt.Fprintf(writer, "%s\t%d\n", "Chain Length", len(entry.Certs))
+		}
+
+		if len(entry.Certs) > 0 {
+			printKeystoreCert(writer, entry.Certs[0])
+		}
+	}
+
+	writer.Flush()
+
+	return nil
+}
+
+func printKeystoreCert(writer *tabwriter.Writer, c *x509.Certificate) {
+	fmt.Fprintf(writer, "%s\t%s\n", "Subject", c.Subject.String())
+	if c.Subject.String() != c.Issuer.String() {
+		fmt.Fprintf(writer, "%s\t%s\n", "Issuer", c.Issuer.String())
+	}
+	fmt.Fprintf(writer, "%s\t%s\n", "Expiration Date", c.NotAfter.String())
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// getOutput validates the output format
+func getOutput(output string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(output)) {
+	case outputText, "":
+		return outputText, nil
+	case outputJSON:
+		return outputJSON, nil
+	case outputYAML, "yml":
+		return outputYAML, nil
+	}
+
+	return "", fmt.Errorf("Invalid output format: %s, must be text, json or yaml", output)
+}
+
+// printOutput prints v in JSON or YAML
+func printOutput(output string, v interface{}) error {
+	switch output {
+	case outputJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case outputYAML:
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(v); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+
+	return fmt.Errorf("Invalid output format: %s", output)
+}
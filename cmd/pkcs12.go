@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chenzhiwei/certctl/pkg/cert"
+)
+
+var (
+	p12Certfile    string
+	p12Keyfile     string
+	p12Chainfile   string
+	p12File        string
+	p12Name        string
+	p12PassFile    string
+	p12PassEnv     string
+	p12KeyPassFile string
+	p12KeyPassEnv  string
+	p12Inform      string
+	p12InFile      string
+	p12OutCert     string
+	p12OutKey      string
+	p12OutChain    string
+	p12Outform     string
+
+	pkcs12Long string = `Export or import PKCS#12(PFX) file.
+
+Examples:
+  # Export certificate, private key and CA certificates to PKCS#12 file
+  certctl pkcs12 export --cert any.com.crt --key any.com.key --chain ca.crt \
+      --out any.com.p12 --password-file password.txt
+
+  # Export certificates only to PKCS#12 file
+  certctl pkcs12 export --cert ca.crt --out ca.p12 --password-env P12_PASSWORD
+
+  # Import PKCS#12 file to PEM certificate, private key and CA certificates
+  certctl pkcs12 import --in any.com.p12 --password-file password.txt \
+      --cert any.com.crt --key any.com.key --chain ca.crt
+`
+
+	pkcs12Cmd = &cobra.Command{
+		Use:     "pkcs12",
+		Aliases: []string{"p12", "pfx"},
+		Short:   "Export or import PKCS#12(PFX) file",
+		Long:    pkcs12Long,
+	}
+
+	pkcs12ExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export PEM certificate and private key to PKCS#12 file",
+		Args:  cobra.MaximumNArgs(0),
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := runPKCS12Export(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	pkcs12ImportCmd = &cobra.Command{
+		Use:   "import",
+		Short: "Import PKCS#12 file to PEM certificate and private key",
+		Args:  cobra.MaximumNArgs(0),
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := runPKCS12Import(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	pkcs12ExportCmd.Flags().StringVar(&p12Certfile, "cert", "", "the certificate file, the extra certificates are treated as CA certificates")
+	pkcs12ExportCmd.Flags().StringVar(&p12Keyfile, "key", "", "the private key file of certificate")
+	pkcs12ExportCmd.Flags().StringVar(&p12Chainfile, "chain", "", "the CA certificates file")
+	pkcs12ExportCmd.Flags().StringVar(&p12File, "out", "certctl.p12", "the output PKCS#12 file")
+	pkcs12ExportCmd.Flags().StringVar(&p12Name, "name", "", "the friendly name of certificate and private key")
+	pkcs12ExportCmd.Flags().StringVar(&p12PassFile, "password-file", "", "the file contains PKCS#12 password")
+	pkcs12ExportCmd.Flags().StringVar(&p12PassEnv, "password-env", "", "the environment variable contains PKCS#12 password")
+	pkcs12ExportCmd.Flags().StringVar(&p12KeyPassFile, "key-passphrase-file", "", "the file contains passphrase to decrypt the private key")
+	pkcs12ExportCmd.Flags().StringVar(&p12KeyPassEnv, "key-passphrase-env", "", "the environment variable contains passphrase to decrypt the private key")
+	pkcs12ExportCmd.Flags().StringVar(&p12Inform, "inform", "", "the input format of certificate, private key and CA certificates: pem, der or pkcs7, detected automatically if not provided")
+	pkcs12ExportCmd.Flags().SortFlags = false
+	pkcs12ExportCmd.MarkFlagRequired("cert")
+
+	pkcs12ImportCmd.Flags().StringVar(&p12InFile, "in", "", "the input PKCS#12 file")
+	pkcs12ImportCmd.Flags().StringVar(&p12OutCert, "cert", "certctl.crt", "the output certificate file")
+	pkcs12ImportCmd.Flags().StringVar(&p12OutKey, "key", "certctl.key", "the output private key file")
+	pkcs12ImportCmd.Flags().StringVar(&p12OutChain, "chain", "", "the output CA certificates file, append to certificate file if not provided")
+	pkcs12ImportCmd.Flags().StringVar(&p12PassFile, "password-file", "", "the file contains PKCS#12 password")
+	pkcs12ImportCmd.Flags().StringVar(&p12PassEnv, "password-env", "", "the environment variable contains PKCS#12 password")
+	pkcs12ImportCmd.Flags().StringVar(&p12KeyPassFile, "key-passphrase-file", "", "the file contains passphrase to encrypt the private key")
+	pkcs12ImportCmd.Flags().StringVar(&p12KeyPassEnv, "key-passphrase-env", "", "the environment variable contains passphrase to encrypt the private key")
+	pkcs12ImportCmd.Flags().StringVar(&p12Outform, "outform", cert.FormatPEM, "the output format of certificate, private key and CA certificates: pem, der or pkcs7")
+	pkcs12ImportCmd.Flags().SortFlags = false
+	pkcs12ImportCmd.MarkFlagRequired("in")
+
+	pkcs12Cmd.AddCommand(pkcs12ExportCmd)
+	pkcs12Cmd.AddCommand(pkcs12ImportCmd)
+}
+
+func runPKCS12Export() error {
+	password, err := readPassphrase(p12PassFile, p12PassEnv)
+	if err != nil {
+		return err
+	}
+
+	certBytes, err := readInput(p12Certfile, p12Inform, cert.CertBlockType)
+	if err != nil {
+		return err
+	}
+	certs, err := cert.ParseCerts(certBytes)
+	if err != nil {
+		return err
+	}
+
+	if p12Chainfile != "" {
+		chainBytes, err := readInput(p12Chainfile, p12Inform, cert.CertBlockType)
+		if err != nil {
+			return err
+		}
+		chain, err := cert.ParseCerts(chainBytes)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, chain...)
+	}
+
+	var p12Bytes []byte
+	if p12Keyfile != "" {
+		keyPassphrase, err := readPassphrase(p12KeyPassFile, p12KeyPassEnv)
+		if err != nil {
+			return err
+		}
+		key, err := readSigner(p12Keyfile, p12Inform, keyPassphrase)
+		if err != nil {
+			return err
+		}
+
+		if !cert.PublicKeyEqual(certs[0].PublicKey, key.Public()) {
+			return fmt.Errorf("Failed to verify Certificate and Key: private key does not match public key")
+		}
+
+		p12Bytes, err = cert.EncodePKCS12(key, certs[0], certs[1:], string(password), p12Name)
+		if err != nil {
+			return err
+		}
+	} else {
+		p12Bytes, err = cert.EncodePKCS12(nil, nil, certs, string(password), p12Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := os.WriteFile(p12File, p12Bytes, 0600); err != nil {
+		return err
+	}
+	fmt.Printf("Writing new PKCS#12 file to '%s'\n", p12File)
+
+	return nil
+}
+
+func runPKCS12Import() error {
+	password, err := readPassphrase(p12PassFile, p12PassEnv)
+	if err != nil {
+		return err
+	}
+
+	keyPassphrase, err := readPassphrase(p12KeyPassFile, p12KeyPassEnv)
+	if err != nil {
+		return err
+	}
+
+	p12Bytes, err := os.ReadFile(p12InFile)
+	if err != nil {
+		return err
+	}
+	p12, err := cert.DecodePKCS12(p12Bytes, string(password))
+	if err != nil {
+		return err
+	}
+
+	if p12.Key != nil {
+		key, ok := p12.Key.(crypto.Signer)
+		if !ok {
+			return fmt.Errorf("Unsupported private key type: %T", p12.Key)
+		}
+		if err := writeKey(p12OutKey, "", key, keyPassphrase, cert.KDFPBKDF2, p12Outform); err != nil {
+			return err
+		}
+	}
+
+	var certs []*x509.Certificate
+	if p12.Cert != nil {
+		certs = append(certs, p12.Cert)
+	}
+
+	if p12OutChain != "" && len(p12.CACerts) > 0 {
+		if err := writeOutput(p12OutChain, cert.EncodeCerts(p12.CACerts), p12Outform, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Writing CA certificates to '%s'\n", p12OutChain)
+	} else {
+		certs = append(certs, p12.CACerts...)
+	}
+
+	if len(certs) > 0 {
+		if err := writeOutput(p12OutCert, cert.EncodeCerts(certs), p12Outform, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Writing certificate to '%s'\n", p12OutCert)
+	}
+
+	return nil
+}
@@ -21,6 +21,19 @@ func init() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(gencaCmd)
 	rootCmd.AddCommand(generateCmd)
+	rootCmd.AddCommand(csrCmd)
+	rootCmd.AddCommand(pkcs12Cmd)
+	rootCmd.AddCommand(keystoreCmd)
+}
+
+// ExitError is the error with the exit code of command
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
 }
 
 func Execute() error {
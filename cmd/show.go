@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"crypto"
+	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"io"
@@ -12,9 +14,19 @@ import (
 )
 
 var (
+	showPassFile string
+	showPassEnv  string
+	showInform   string
+	showOutput   string
+	showText     bool
+	showFPOnly   bool
+	showChain    bool
+	showTmpl     string
+	showTmplFile string
+
 	showCmd = &cobra.Command{
 		Use:   "show cert-or-csr-filepath or - from stdin",
-		Short: "Show certificate or certificate request info",
+		Short: "Show certificate, certificate request, key or PKCS#12 info",
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
 			if err := runShow(args); err != nil {
@@ -25,6 +37,19 @@ var (
 	}
 )
 
+func init() {
+	showCmd.Flags().StringVar(&showPassFile, "password-file", "", "the file contains PKCS#12 password or private key passphrase")
+	showCmd.Flags().StringVar(&showPassEnv, "password-env", "", "the environment variable contains PKCS#12 password or private key passphrase")
+	showCmd.Flags().StringVar(&showInform, "inform", "", "the input format: pem, der or pkcs7, detected automatically if not provided")
+	showCmd.Flags().StringVar(&showOutput, "output", outputText, "the output format: text, json or yaml")
+	showCmd.Flags().BoolVar(&showText, "text", false, "print the full text dump of certificate, including all the extensions")
+	showCmd.Flags().BoolVar(&showFPOnly, "fingerprint-only", false, "print only the fingerprints and SPKI pin, one line per certificate")
+	showCmd.Flags().BoolVar(&showChain, "chain", false, "print the certificates as a chain tree from leaf to root")
+	showCmd.Flags().StringVar(&showTmpl, "template", "", "the Go template evaluated against each certificate, e.g. '{{.Subject.CommonName}} {{.NotAfter}}'")
+	showCmd.Flags().StringVar(&showTmplFile, "template-file", "", "the file contains Go template evaluated against each certificate")
+	showCmd.MarkFlagsMutuallyExclusive("template", "template-file")
+}
+
 func runShow(args []string) error {
 	file := args[0]
 	data, err := os.ReadFile(file)
@@ -36,9 +61,89 @@ func runShow(args []string) error {
 		}
 	}
 
-	block, _ := pem.Decode(data)
+	output, err := getOutput(showOutput)
+	if err != nil {
+		return err
+	}
+
+	format, err := cert.GetFormat(showInform)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		if cert.IsPKCS12(data) {
+			return showPKCS12(data, output)
+		}
+		format = cert.DetectFormat(data)
+	}
+
+	// the DER data can be certificate, certificate request or key
+	blockType := cert.CertBlockType
+	if format == cert.FormatDER {
+		blockType = cert.DetectDERBlockType(data)
+	}
+
+	data, err = cert.ToPEM(data, format, blockType)
+	if err != nil {
+		return fmt.Errorf("Failed to parse certificate, csr or key: %w", err)
+	}
+
+	block, rest := pem.Decode(data)
+	if block != nil && block.Type == cert.ECParamsBlockType {
+		block, _ = pem.Decode(rest)
+	}
 	if block == nil {
-		return fmt.Errorf("Failed to parse certificate or csr")
+		return fmt.Errorf("Failed to parse certificate, csr or key")
+	}
+
+	// the certificates of mixed PEM bundle are also printed
+	mixed := cert.IsMixedBundle(data)
+
+	if showFPOnly {
+		if block.Type != cert.CertBlockType && !mixed {
+			return fmt.Errorf("Unsupported type: %s, fingerprints are only for certificates", block.Type)
+		}
+		return printFingerprints(data)
+	}
+
+	if showTmpl != "" || showTmplFile != "" {
+		if block.Type != cert.CertBlockType && !mixed {
+			return fmt.Errorf("Unsupported type: %s, template is only for certificates", block.Type)
+		}
+		return printTemplate(data)
+	}
+
+	if showChain {
+		if block.Type != cert.CertBlockType && !mixed {
+			return fmt.Errorf("Unsupported type: %s, chain is only for certificates", block.Type)
+		}
+		return printChain(data, output)
+	}
+
+	if mixed && (!showText || output != outputText) {
+		return showBundle(data, output)
+	}
+
+	if cert.IsKeyBlockType(block.Type) && !mixed {
+		return showKey(data, output)
+	}
+
+	if output != outputText {
+		return printShowOutput(data, block, output)
+	}
+
+	if showText && (block.Type == cert.CertBlockType || mixed) {
+		certs, err := cert.ParseCerts(data)
+		if err != nil {
+			return err
+		}
+		for i, c := range certs {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Print(cert.GetCertText(c))
+		}
+		return nil
 	}
 
 	var result []map[string]string
@@ -66,12 +171,219 @@ func runShow(args []string) error {
 
 	writer.Flush()
 
-	// a certificate/request can contain too many tings, no need to reinvent the wheel
-	if block.Type == cert.CertReqBlockType {
-		fmt.Printf("\nCheck more info with: openssl req -noout -text -in %s\n", file)
-	} else if block.Type == cert.CertBlockType {
-		fmt.Printf("\nCheck more info with: openssl x509 -noout -text -in %s\n", file)
+	if block.Type == cert.CertBlockType {
+		fmt.Printf("\nCheck more info with: certctl show --text %s\n", file)
+	}
+
+	return nil
+}
+
+// printShowOutput prints the certificates or certificate request in JSON or YAML
+func printShowOutput(data []byte, block *pem.Block, output string) error {
+	switch block.Type {
+	case cert.CertReqBlockType:
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("Failed to parse certificate request: %w", err)
+		}
+		return printOutput(output, cert.NewCertificateRequestInfo(csr))
+	case cert.CertBlockType:
+		infos, err := cert.GetCertificateInfos(data)
+		if err != nil {
+			return err
+		}
+		return printOutput(output, infos)
+	}
+
+	return fmt.Errorf("Unsupported type: %s", block.Type)
+}
+
+// showBundle prints every PEM block of mixed PEM bundle
+func showBundle(data []byte, output string) error {
+	passphrase, err := readPassphrase(showPassFile, showPassEnv)
+	if err != nil {
+		return err
+	}
+
+	if output != outputText {
+		entries, err := cert.ParseBundle(data, passphrase)
+		if err != nil {
+			return err
+		}
+		return printOutput(output, cert.NewBundleInfo(entries))
+	}
+
+	result, err := cert.GetBundleInfo(data, passphrase)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+	for _, info := range result {
+		for k, v := range info {
+			fmt.Fprintf(writer, "%s\t%s\n", k, v)
+		}
+	}
+
+	writer.Flush()
+
+	return nil
+}
+
+// showKey prints the private key or public key info
+func showKey(data []byte, output string) error {
+	passphrase, err := readPassphrase(showPassFile, showPassEnv)
+	if err != nil {
+		return err
+	}
+
+	if output != outputText {
+		info, err := cert.ParseKeyInfo(data, passphrase)
+		if err != nil {
+			return err
+		}
+		return printOutput(output, info)
 	}
 
+	result, err := cert.GetKeyInfo(data, passphrase)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+	for _, info := range result {
+		for k, v := range info {
+			fmt.Fprintf(writer, "%s\t%s\n", k, v)
+		}
+	}
+
+	writer.Flush()
+
 	return nil
 }
+
+func showPKCS12(data []byte, output string) error {
+	password, err := readPassphrase(showPassFile, showPassEnv)
+	if err != nil {
+		return err
+	}
+
+	p12, err := cert.DecodePKCS12(data, string(password))
+	if err != nil {
+		return err
+	}
+
+	var certs []*x509.Certificate
+	if p12.Cert != nil {
+		certs = append(certs, p12.Cert)
+	}
+	certs = append(certs, p12.CACerts...)
+
+	if showFPOnly {
+		return printFingerprints(cert.EncodeCerts(certs))
+	}
+	if showChain {
+		return printChain(cert.EncodeCerts(certs), output)
+	}
+	if showTmpl != "" || showTmplFile != "" {
+		return printTemplate(cert.EncodeCerts(certs))
+	}
+
+	if output != outputText {
+		info := &cert.PKCS12Info{FriendlyName: p12.FriendlyName}
+		if key, ok := p12.Key.(crypto.Signer); ok {
+			info.PrivateKey = cert.GetKeyType(key.Public())
+		}
+		if p12.Cert != nil {
+			info.Certificates = append(info.Certificates, cert.NewCertificateInfo(p12.Cert))
+		}
+		for _, caCert := range p12.CACerts {
+			info.Certificates = append(info.Certificates, cert.NewCertificateInfo(caCert))
+		}
+		return printOutput(output, info)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+	fmt.Fprintf(writer, "%s\t%s\n", "Format", "PKCS#12")
+	if p12.FriendlyName != "" {
+		fmt.Fprintf(writer, "%s\t%s\n", "Friendly Name", p12.FriendlyName)
+	}
+	if p12.Key != nil {
+		key, ok := p12.Key.(crypto.Signer)
+		if ok {
+			fmt.Fprintf(writer, "%s\t%s\n", "Private Key", cert.GetKeyType(key.Public()))
+		}
+	}
+
+	if len(certs) > 0 {
+		result, err := cert.GetCertInfo(cert.EncodeCerts(certs))
+		if err != nil {
+			return err
+		}
+
+		for _, info := range result {
+			for k, v := range info {
+				fmt.Fprintf(writer, "%s\t%s\n", k, v)
+			}
+		}
+	}
+
+	writer.Flush()
+
+	return nil
+}
+
+// printFingerprints prints one line of fingerprints per certificate
+func printFingerprints(certBytes []byte) error {
+	lines, err := cert.GetCertFingerprints(certBytes)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+
+	return nil
+}
+
+// printChain prints the certificates as a chain tree from leaf to root
+func printChain(certBytes []byte, output string) error {
+	certs, err := cert.ParseCerts(certBytes)
+	if err != nil {
+		return err
+	}
+
+	info := cert.NewChainInfo(certs)
+	if output != outputText {
+		return printOutput(output, info)
+	}
+
+	fmt.Print(cert.GetChainText(info))
+
+	return nil
+}
+
+// printTemplate prints each certificate with the Go template
+func printTemplate(certBytes []byte) error {
+	text := showTmpl
+	if showTmplFile != "" {
+		data, err := os.ReadFile(showTmplFile)
+		if err != nil {
+			return err
+		}
+		text = string(data)
+	}
+
+	tmpl, err := cert.NewCertTemplate(text)
+	if err != nil {
+		return err
+	}
+
+	infos, err := cert.GetCertificateInfos(certBytes)
+	if err != nil {
+		return err
+	}
+
+	return cert.ExecuteCertTemplate(os.Stdout, tmpl, infos)
+}
@@ -1,9 +1,9 @@
 package cmd
 
 import (
-	"crypto/tls"
+	"crypto"
+	"crypto/x509"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -13,6 +13,8 @@ import (
 
 var (
 	certSize        int
+	certKeyType     string
+	certCurve       string
 	certDays        int
 	certIsCA        bool
 	certSan         string
@@ -23,6 +25,16 @@ var (
 	certCertfile    string
 	certCAKeyfile   string
 	certCACertfile  string
+	certCSRfile     string
+	certCopyExts    string
+	certReuseKey    string
+	certKeyPassFile string
+	certKeyPassEnv  string
+	certKeyKDF      string
+	certCAPassFile  string
+	certCAPassEnv   string
+	certInform      string
+	certOutform     string
 
 	signLong string = `Sign a certificate with CA certificate.
 
@@ -36,6 +48,38 @@ Examples:
       --extusage serverAuth,clientAuth \
       --days 730 --size 2048
 
+  # Sign a certificate with Ed25519 private key
+  certctl sign --ca-key ca.key --ca-cert ca.crt \
+      --subject "CN=anycorp.com" --san anycorp.com \
+      --key anycorp.com.key --cert anycorp.com.crt \
+      --usage digitalSignature --extusage serverAuth \
+      --key-type ed25519
+
+  # Sign a certificate signing request, the private key is not needed
+  certctl sign --ca-key ca.key --ca-cert ca.crt \
+      --csr anycorp.com.csr --copy-extensions copy \
+      --cert anycorp.com.crt \
+      --usage digitalSignature,keyEncipherment \
+      --extusage serverAuth,clientAuth
+
+  # Renew a certificate with the existing private key
+  certctl sign --ca-key ca.key --ca-cert ca.crt \
+      --subject "CN=anycorp.com" --san anycorp.com \
+      --reuse-key anycorp.com.key --cert anycorp.com.crt
+
+  # Sign a certificate with encrypted CA key and encrypt the new private key
+  certctl sign --ca-key ca.key --ca-cert ca.crt \
+      --ca-key-passphrase-env CA_PASSPHRASE \
+      --subject "CN=anycorp.com" --san anycorp.com \
+      --key anycorp.com.key --cert anycorp.com.crt \
+      --key-passphrase-file passphrase.txt
+
+The list of copy extensions policies are:
+  * none: use the --subject and --san only
+  * copy: use the request subject if no --subject provided, and merge the
+          request subject alternative names with --san
+  * copyall: use the request subject and subject alternative names
+
 The list of key usages are:
   * digitalSignature
   * contentCommitment
@@ -85,29 +129,55 @@ func init() {
 	signCmd.Flags().StringVar(&certKeyUsage, "usage", "", "the certificate key usage")
 	signCmd.Flags().StringVar(&certExtKeyUsage, "extusage", "", "the certificate extended key usage")
 	signCmd.Flags().IntVar(&certDays, "days", 365, "the certificate validation period")
+	signCmd.Flags().StringVar(&certKeyType, "key-type", "rsa", "the private key type: rsa, ecdsa or ed25519")
 	signCmd.Flags().IntVar(&certSize, "size", 2048, "the certificate RSA private key size")
+	signCmd.Flags().StringVar(&certCurve, "curve", "P-256", "the ECDSA private key curve: P-256, P-384 or P-521")
 	signCmd.Flags().StringVar(&certKeyfile, "key", "certctl-signed.key", "the output key file")
 	signCmd.Flags().StringVar(&certCertfile, "cert", "certctl-signed.crt", "the output cert file")
+	signCmd.Flags().StringVar(&certReuseKey, "reuse-key", "", "reuse the existing private key file instead of generating a new one")
+	signCmd.Flags().StringVar(&certKeyPassFile, "key-passphrase-file", "", "the file contains passphrase to encrypt or decrypt the private key")
+	signCmd.Flags().StringVar(&certKeyPassEnv, "key-passphrase-env", "", "the environment variable contains passphrase to encrypt or decrypt the private key")
+	signCmd.Flags().StringVar(&certKeyKDF, "key-kdf", cert.KDFPBKDF2, "the key derivation function to encrypt the private key: pbkdf2 or scrypt")
 	signCmd.Flags().StringVar(&certCAKeyfile, "ca-key", "", "the ca key file to sign certificate")
 	signCmd.Flags().StringVar(&certCACertfile, "ca-cert", "", "the ca cert file to sign certificate")
+	signCmd.Flags().StringVar(&certCAPassFile, "ca-key-passphrase-file", "", "the file contains passphrase to decrypt the ca key")
+	signCmd.Flags().StringVar(&certCAPassEnv, "ca-key-passphrase-env", "", "the environment variable contains passphrase to decrypt the ca key")
+	signCmd.Flags().StringVar(&certCSRfile, "csr", "", "the certificate signing request file to sign")
+	signCmd.Flags().StringVar(&certCopyExts, "copy-extensions", cert.CopyExtensionsNone, "the policy of copying subject and subject alternate names from csr: none, copy or copyall")
+	signCmd.Flags().StringVar(&certInform, "inform", "", "the input format of ca cert, ca key, csr and reused key: pem, der or pkcs7, detected automatically if not provided")
+	signCmd.Flags().StringVar(&certOutform, "outform", cert.FormatPEM, "the output format of certificate and private key: pem or der")
 
 	signCmd.Flags().SortFlags = false
-	signCmd.MarkFlagRequired("subject")
 	signCmd.MarkFlagRequired("ca-key")
 	signCmd.MarkFlagRequired("ca-cert")
+	signCmd.MarkFlagsMutuallyExclusive("reuse-key", "key-type")
+	signCmd.MarkFlagsMutuallyExclusive("reuse-key", "size")
+	signCmd.MarkFlagsMutuallyExclusive("reuse-key", "curve")
 }
 
 func runSign() error {
-	caKeyBytes, err := os.ReadFile(certCAKeyfile)
+	if certSubject == "" && (certCSRfile == "" || certCopyExts == cert.CopyExtensionsNone) {
+		return fmt.Errorf("unable to sign, please provide --subject")
+	}
+
+	if certCSRfile != "" && certReuseKey != "" {
+		return fmt.Errorf("unable to sign, --csr and --reuse-key are mutually exclusive")
+	}
+
+	if _, err := cert.GetKDF(certKeyKDF); err != nil {
+		return err
+	}
+
+	caPassphrase, err := readPassphrase(certCAPassFile, certCAPassEnv)
 	if err != nil {
 		return err
 	}
-	caKey, err := cert.ParseKey(caKeyBytes)
+	caKey, err := readSigner(certCAKeyfile, certInform, caPassphrase)
 	if err != nil {
 		return err
 	}
 
-	caCertBytes, err := os.ReadFile(certCACertfile)
+	caCertBytes, err := readInput(certCACertfile, certInform, cert.CertBlockType)
 	if err != nil {
 		return err
 	}
@@ -117,27 +187,68 @@ func runSign() error {
 	}
 
 	// return error if it is an invalid CA keypair
-	if _, err := tls.X509KeyPair(caCertBytes, caKeyBytes); err != nil {
-		return fmt.Errorf("Failed to verify Certificate and Key: %w", err)
+	if !cert.PublicKeyEqual(caCert.PublicKey, caKey.Public()) {
+		return fmt.Errorf("Failed to verify Certificate and Key: private key does not match public key")
 	}
 
 	duration := time.Hour * 24 * time.Duration(certDays)
+
+	if certCSRfile != "" {
+		return runSignCSR(caCert, caKey, duration)
+	}
+
 	certInfo, err := cert.NewCertInfo(duration, certSubject, certSan, certKeyUsage, certExtKeyUsage, certIsCA)
 	if err != nil {
 		return err
 	}
 
-	certBytes, keyBytes, err := cert.NewSignedCertKey(caCert, caKey, certInfo, certSize)
+	passphrase, err := readPassphrase(certKeyPassFile, certKeyPassEnv)
+	if err != nil {
+		return err
+	}
+
+	key, err := newSigner(certReuseKey, certInform, passphrase, certKeyType, certSize, certCurve)
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(certKeyfile, keyBytes, 0600); err != nil {
+	certBytes, err := cert.NewSignedCert(caCert, caKey, certInfo, key.Public())
+	if err != nil {
+		return err
+	}
+
+	if err := writeKey(certKeyfile, certReuseKey, key, passphrase, certKeyKDF, certOutform); err != nil {
+		return err
+	}
+
+	if err := writeOutput(certCertfile, certBytes, certOutform, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Writing new certificate to '%s'\n", certCertfile)
+	return nil
+}
+
+func runSignCSR(caCert *x509.Certificate, caKey crypto.Signer, duration time.Duration) error {
+	csrBytes, err := readInput(certCSRfile, certInform, cert.CertReqBlockType)
+	if err != nil {
+		return err
+	}
+	csr, err := cert.ParseCertRequest(csrBytes)
+	if err != nil {
+		return err
+	}
+
+	certInfo, err := cert.NewCertInfoFromRequest(csr, certCopyExts, duration, certSubject, certSan, certKeyUsage, certExtKeyUsage, certIsCA)
+	if err != nil {
+		return err
+	}
+
+	certBytes, err := cert.NewSignedCert(caCert, caKey, certInfo, csr.PublicKey)
+	if err != nil {
 		return err
 	}
-	fmt.Printf("Writing new private key to '%s'\n", certKeyfile)
 
-	if err := os.WriteFile(certCertfile, certBytes, 0644); err != nil {
+	if err := writeOutput(certCertfile, certBytes, certOutform, 0644); err != nil {
 		return err
 	}
 	fmt.Printf("Writing new certificate to '%s'\n", certCertfile)
@@ -1,23 +1,44 @@
 package cmd
 
 import (
-	"crypto/tls"
+	"crypto"
 	"crypto/x509"
-	"encoding/pem"
 	"fmt"
+	"net"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/chenzhiwei/certctl/pkg/cert"
 )
 
 var (
 	crtCAFile   string
 	crtKeyFile  string
 	crtCertFile string
+	crtPassFile string
+	crtPassEnv  string
+	crtInform   string
+	crtOutput   string
+	crtHost     string
+	crtIP       string
+	crtUntrust  string
+	crtSysRoots bool
+	crtAIA      bool
+	crtPurpose  string
+	crtAt       string
+	crtIn       string
+	crtCRLFiles []string
+	crtCRLFetch bool
+	crtOCSP     bool
+	crtOCSPURL  string
+	crtCSRFile  string
 
 	verifyCmd = &cobra.Command{
 		Use:   "verify",
-		Short: "Verify certificate keypair and CA",
+		Short: "Verify certificate keypair, CSR and CA",
 		Args:  cobra.MaximumNArgs(0),
 		RunE: func(_ *cobra.Command, _ []string) error {
 			if err := runVerify(); err != nil {
@@ -29,68 +50,465 @@ var (
 )
 
 func init() {
-	verifyCmd.Flags().StringVar(&crtCAFile, "ca", "", "the CA certificate file")
-	verifyCmd.Flags().StringVar(&crtKeyFile, "key", "", "the certificate file")
-	verifyCmd.Flags().StringVar(&crtCertFile, "cert", "", "the private key file")
+	verifyCmd.Flags().StringVar(&crtCAFile, "ca", "", "the CA certificate file, may contain multiple trusted roots")
+	verifyCmd.Flags().StringVar(&crtUntrust, "untrusted", "", "the intermediate certificates file, the extra certificates in --cert are also used as intermediates")
+	verifyCmd.Flags().BoolVar(&crtSysRoots, "system-roots", false, "verify against the system trust store, together with --ca if provided")
+	verifyCmd.Flags().BoolVar(&crtAIA, "aia", false, "fetch the missing intermediate certificates from the CA Issuers URLs in AIA extension")
+	verifyCmd.Flags().StringVar(&crtKeyFile, "key", "", "the private key file")
+	verifyCmd.Flags().StringVar(&crtCertFile, "cert", "", "the certificate file")
+	verifyCmd.Flags().StringVar(&crtCSRFile, "csr", "", "the certificate request file to match with the private key and/or the certificate")
+	verifyCmd.Flags().StringVar(&crtPassFile, "key-passphrase-file", "", "the file contains passphrase to decrypt the private key")
+	verifyCmd.Flags().StringVar(&crtPassEnv, "key-passphrase-env", "", "the environment variable contains passphrase to decrypt the private key")
+	verifyCmd.Flags().StringVar(&crtInform, "inform", "", "the input format of certificates and private key: pem, der or pkcs7, detected automatically if not provided")
+	verifyCmd.Flags().StringVar(&crtOutput, "output", outputText, "the output format: text, json or yaml")
+	verifyCmd.Flags().StringVar(&crtHost, "host", "", "the hostname to verify against the DNS SANs, wildcard SANs are supported")
+	verifyCmd.Flags().StringVar(&crtIP, "ip", "", "the IP address to verify against the IP SANs")
+	verifyCmd.Flags().StringVar(&crtPurpose, "purpose", "", "the purpose to verify the certificate and chain for: serverAuth, clientAuth, codeSigning, emailProtection or any")
+	verifyCmd.Flags().StringVar(&crtAt, "at", "", "verify at the time instead of now, like 2027-01-01T00:00:00Z or 2027-01-01, and print how long until each certificate expires")
+	verifyCmd.Flags().StringVar(&crtIn, "in", "", "verify at the time after the duration from now, like 30d or 12h, and print how long until each certificate expires")
+	verifyCmd.Flags().StringArrayVar(&crtCRLFiles, "crl", nil, "the CRL file in PEM or DER format to check the revocation, can be repeated")
+	verifyCmd.Flags().BoolVar(&crtCRLFetch, "crl-fetch", false, "fetch the CRLs from the CRL distribution points of the certificates")
+	verifyCmd.Flags().BoolVar(&crtOCSP, "ocsp", false, "check the OCSP status of the certificate with the OCSP responder in AIA extension")
+	verifyCmd.Flags().StringVar(&crtOCSPURL, "ocsp-url", "", "check the OCSP status with the OCSP responder URL instead of the one in AIA extension")
 
 	verifyCmd.Flags().SortFlags = false
-	verifyCmd.MarkFlagRequired("cert")
+	verifyCmd.MarkFlagsMutuallyExclusive("host", "ip")
+	verifyCmd.MarkFlagsMutuallyExclusive("at", "in")
 }
 
 func runVerify() error {
-	certBytes, err := os.ReadFile(crtCertFile)
+	output, err := getOutput(crtOutput)
 	if err != nil {
 		return err
 	}
 
-	if crtCAFile == "" && crtKeyFile == "" {
-		return fmt.Errorf("unable to verify, please provide --ca and/or --key")
+	if crtCertFile == "" {
+		return runVerifyCSR(output)
 	}
 
-	if crtCAFile != "" {
-		caBytes, err := os.ReadFile(crtCAFile)
+	certBytes, err := readInput(crtCertFile, crtInform, cert.CertBlockType)
+	if err != nil {
+		return err
+	}
+
+	host := crtHost
+	if crtIP != "" {
+		if net.ParseIP(crtIP) == nil {
+			return fmt.Errorf("invalid IP address: %s", crtIP)
+		}
+		host = crtIP
+	}
+
+	var purpose x509.ExtKeyUsage
+	if crtPurpose != "" {
+		purpose, err = cert.ParsePurpose(crtPurpose)
 		if err != nil {
 			return err
 		}
+	}
 
-		roots := x509.NewCertPool()
-		ok := roots.AppendCertsFromPEM(caBytes)
-		if !ok {
-			return fmt.Errorf("unable to parse CA certificate")
+	// the zero time means now
+	var at time.Time
+	if crtAt != "" {
+		at, err = cert.ParseTime(crtAt)
+		if err != nil {
+			return err
 		}
-
-		block, _ := pem.Decode(certBytes)
-		if block == nil {
-			return fmt.Errorf("unable to parse certificate PEM")
+	}
+	if crtIn != "" {
+		d, err := cert.ParseDuration(crtIn)
+		if err != nil {
+			return err
 		}
+		at = time.Now().Add(d)
+	}
 
-		cert, err := x509.ParseCertificate(block.Bytes)
+	verifyChain := crtCAFile != "" || crtSysRoots
+	if !verifyChain && crtKeyFile == "" && crtCSRFile == "" && host == "" && crtPurpose == "" && at.IsZero() {
+		err := fmt.Errorf("unable to verify, please provide --ca, --system-roots, --key, --csr, --host, --ip, --purpose, --at or --in")
+		return &ExitError{Code: cert.ExitError, Err: err}
+	}
+	if !verifyChain && crtUntrust != "" {
+		return fmt.Errorf("--untrusted requires --ca or --system-roots")
+	}
+	if !verifyChain && crtAIA {
+		return fmt.Errorf("--aia requires --ca or --system-roots")
+	}
+	checkCRL := len(crtCRLFiles) > 0 || crtCRLFetch
+	if !verifyChain && checkCRL {
+		return fmt.Errorf("--crl and --crl-fetch require --ca or --system-roots")
+	}
+	checkOCSP := crtOCSP || crtOCSPURL != ""
+	if !verifyChain && checkOCSP {
+		return fmt.Errorf("--ocsp and --ocsp-url require --ca or --system-roots")
+	}
+
+	certs, err := cert.ParseCerts(certBytes)
+	if err != nil {
+		return err
+	}
+	crt := certs[0]
+
+	inputs, err := readVerifyInputs()
+	if err != nil {
+		return &ExitError{Code: cert.ExitError, Err: err}
+	}
+
+	result := &cert.VerifyResult{Certificate: cert.NewCertificateInfo(crt)}
+
+	// the chain is verified regardless of the validity period and hostname,
+	// which are reported by their own checks
+	opts := &cert.VerifyOptions{
+		Roots:          inputs.roots,
+		Intermediates:  append(certs[1:], inputs.untrusted...),
+		SystemRoots:    crtSysRoots,
+		CurrentTime:    at,
+		IgnoreValidity: true,
+	}
+
+	var chains [][]*x509.Certificate
+	if verifyChain {
+		chains, err = verifyCA(crt, opts)
+		result.CA = cert.NewVerifyCheck(err)
+		result.Chains = cert.NewVerifiedChains(chains)
 		if err != nil {
-			return fmt.Errorf("unable to parse certificate: %w\n", err)
+			result.Checks = append(result.Checks, cert.FailCheck(cert.ReportChain, cert.ExitChain, err.Error()))
+		} else {
+			result.Checks = append(result.Checks, cert.PassCheck(cert.ReportChain, fmt.Sprintf("%d chain(s) found to the trusted roots", len(chains))))
 		}
+	} else {
+		result.Checks = append(result.Checks, cert.SkipCheck(cert.ReportChain, "--ca or --system-roots is not provided"))
+	}
+
+	// the certificates of the chains if verified, otherwise of --cert
+	validityAt := at
+	if validityAt.IsZero() {
+		validityAt = time.Now()
+	}
+	expiryCerts := certs
+	if len(chains) > 0 {
+		expiryCerts = cert.GetChainsCerts(chains)
+	}
+	result.At = &validityAt
+	result.Expiry = cert.NewCertExpiries(expiryCerts, validityAt)
+	if summary, err := cert.CheckExpiries(result.Expiry, validityAt); err != nil {
+		result.Checks = append(result.Checks, cert.FailCheck(cert.ReportValidity, cert.ExitValidity, err.Error()))
+	} else {
+		result.Checks = append(result.Checks, cert.PassCheck(cert.ReportValidity, summary))
+	}
 
-		opts := x509.VerifyOptions{
-			Roots: roots,
+	if host != "" {
+		result.Hostname = cert.MatchHostname(crt, host)
+		result.Checks = append(result.Checks, reportHostname(result.Hostname))
+	} else {
+		result.Checks = append(result.Checks, cert.SkipCheck(cert.ReportHostname, "--host or --ip is not provided"))
+	}
+
+	if crtPurpose != "" {
+		result.Purpose = cert.CheckPurpose(crt, purpose)
+		if len(chains) > 0 {
+			opts.Purpose = purpose
+			_, err := verifyCA(crt, opts)
+			result.Purpose.ChainExtKeyUsage = cert.NewVerifyCheck(err)
 		}
+		result.Checks = append(result.Checks, reportPurpose(result.Purpose))
+	} else {
+		result.Checks = append(result.Checks, cert.SkipCheck(cert.ReportPurpose, "--purpose is not provided"))
+	}
 
-		if _, err := cert.Verify(opts); err != nil {
-			return fmt.Errorf("unable to verify certificate: %w", err)
+	if crtKeyFile != "" {
+		result.Key = cert.NewVerifyCheck(verifyKey(crt.PublicKey, inputs.key))
+		if result.Key.Verified {
+			result.Checks = append(result.Checks, cert.PassCheck(cert.ReportKey, "the certificate matches private key"))
 		} else {
-			fmt.Println("Verified OK: the certificate matches CA")
+			result.Checks = append(result.Checks, cert.FailCheck(cert.ReportKey, cert.ExitMismatch, result.Key.Error))
+		}
+	} else {
+		result.Checks = append(result.Checks, cert.SkipCheck(cert.ReportKey, "--key is not provided"))
+	}
+
+	if crtCSRFile != "" {
+		result.CSR = verifyCSR(inputs.csr, crt, inputs.key)
+		result.Checks = append(result.Checks, reportCSR(result.CSR))
+	} else {
+		result.Checks = append(result.Checks, cert.SkipCheck(cert.ReportCSR, "--csr is not provided"))
+	}
+
+	switch {
+	case !checkCRL:
+		result.Checks = append(result.Checks, cert.SkipCheck(cert.ReportCRL, "--crl or --crl-fetch is not provided"))
+	case len(chains) == 0:
+		result.Checks = append(result.Checks, cert.SkipCheck(cert.ReportCRL, "the chain is not verified"))
+	default:
+		result.CRL = cert.CheckCRLs(chains[0], inputs.crls, crtCRLFetch, at)
+		result.Checks = append(result.Checks, reportCRL(result.CRL))
+	}
+
+	switch {
+	case !checkOCSP:
+		result.Checks = append(result.Checks, cert.SkipCheck(cert.ReportOCSP, "--ocsp or --ocsp-url is not provided"))
+	case len(chains) == 0:
+		result.Checks = append(result.Checks, cert.SkipCheck(cert.ReportOCSP, "the chain is not verified"))
+	case len(chains[0]) < 2:
+		result.Checks = append(result.Checks, cert.SkipCheck(cert.ReportOCSP, "the certificate is self-signed"))
+	default:
+		result.OCSP = cert.CheckOCSP(chains[0][0], chains[0][1], crtOCSPURL, at)
+		result.Checks = append(result.Checks, reportOCSP(result.OCSP))
+	}
+
+	return printVerifyResult(result, output)
+}
+
+// printVerifyResult prints the report and returns the error with the exit
+// code of the first failed check
+func printVerifyResult(result *cert.VerifyResult, output string) error {
+	result.ExitCode = cert.GetReportExitCode(result.Checks)
+	result.Passed = result.ExitCode == cert.ExitOK
+
+	if output != outputText {
+		if err := printOutput(output, result); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(cert.GetReportText(result.Checks))
+		if len(result.Chains) > 0 {
+			fmt.Printf("\n%s", cert.GetVerifiedChainsText(result.Chains))
+		}
+		if crtAt != "" || crtIn != "" {
+			fmt.Printf("\n%s", cert.GetExpiryText(result.Expiry, *result.At))
+		}
+	}
+
+	if result.Passed {
+		return nil
+	}
+
+	var failed []string
+	for _, c := range result.Checks {
+		if c.Status == cert.StatusFail {
+			failed = append(failed, c.Name)
+		}
+	}
+
+	return &ExitError{Code: result.ExitCode, Err: fmt.Errorf("unable to verify, failed checks: %s", strings.Join(failed, ", "))}
+}
+
+func reportHostname(match *cert.HostnameMatch) *cert.ReportCheck {
+	if !match.Matched {
+		return cert.FailCheck(cert.ReportHostname, cert.ExitHostname, match.Error)
+	}
+
+	for _, c := range match.Checked {
+		if c.Matched {
+			return cert.PassCheck(cert.ReportHostname, fmt.Sprintf("the certificate matches %s by %s", match.Host, c.SAN))
+		}
+	}
+
+	return cert.PassCheck(cert.ReportHostname, fmt.Sprintf("the certificate matches %s", match.Host))
+}
+
+func reportPurpose(check *cert.PurposeCheck) *cert.ReportCheck {
+	for _, c := range []*cert.VerifyCheck{check.ExtKeyUsage, check.KeyUsage, check.ChainExtKeyUsage} {
+		if c != nil && !c.Verified {
+			return cert.FailCheck(cert.ReportPurpose, cert.ExitPurpose, c.Error)
+		}
+	}
+
+	reason := fmt.Sprintf("the extended key usage and key usage allow %s", check.Purpose)
+	if check.ChainExtKeyUsage != nil {
+		reason += ", so does the chain"
+	}
+
+	return cert.PassCheck(cert.ReportPurpose, reason)
+}
+
+func reportCSR(check *cert.CSRCheck) *cert.ReportCheck {
+	var errs []string
+	for _, c := range []*cert.VerifyCheck{check.Signature, check.Key, check.PublicKey, check.Subject, check.SANs} {
+		if c != nil && !c.Verified {
+			errs = append(errs, c.Error)
+		}
+	}
+	if len(errs) > 0 {
+		return cert.FailCheck(cert.ReportCSR, cert.ExitMismatch, strings.Join(errs, ", "))
+	}
+
+	reason := "the CSR signature is valid"
+	if check.Key != nil {
+		reason += ", it matches private key"
+	}
+	if check.PublicKey != nil {
+		reason += ", the certificate has the requested public key, subject and SANs"
+	}
+
+	return cert.PassCheck(cert.ReportCSR, reason)
+}
+
+// reportCRL fails with ExitRevoked if any certificate is revoked, otherwise
+// ExitRevocationUnknown if the status can not be determined
+func reportCRL(checks []*cert.CRLCheck) *cert.ReportCheck {
+	var errs []string
+	exitCode := cert.ExitRevocationUnknown
+	for _, c := range checks {
+		if c.Revoked {
+			exitCode = cert.ExitRevoked
+		}
+		if !c.Verified {
+			errs = append(errs, c.Error)
+		}
+	}
+	if len(errs) > 0 {
+		return cert.FailCheck(cert.ReportCRL, exitCode, strings.Join(errs, ", "))
+	}
+
+	return cert.PassCheck(cert.ReportCRL, fmt.Sprintf("%d certificate(s) are not revoked", len(checks)))
+}
+
+func reportOCSP(check *cert.OCSPCheck) *cert.ReportCheck {
+	switch {
+	case check.Status == cert.OCSPStatusRevoked:
+		return cert.FailCheck(cert.ReportOCSP, cert.ExitRevoked, check.Error)
+	case !check.Verified:
+		return cert.FailCheck(cert.ReportOCSP, cert.ExitRevocationUnknown, check.Error)
+	}
+
+	return cert.PassCheck(cert.ReportOCSP, fmt.Sprintf("the certificate is %s by %s", check.Status, check.Responder))
+}
+
+// verifyCA verifies the certificate against the roots of opts, the extra
+// certificates of --cert and --untrusted are the intermediates
+func verifyCA(crt *x509.Certificate, opts *cert.VerifyOptions) ([][]*x509.Certificate, error) {
+	// the fetched issuers are cached, so it is cheap to verify again. They
+	// only extend the intermediates of this call, opts is shared by checks.
+	verifyOpts := *opts
+	var aiaErr error
+	if crtAIA {
+		var chain []*x509.Certificate
+		chain, aiaErr = cert.CompleteChain(append([]*x509.Certificate{crt}, opts.Intermediates...), opts)
+		intermediates := make([]*x509.Certificate, 0, len(opts.Intermediates)+len(chain)-1)
+		intermediates = append(intermediates, opts.Intermediates...)
+		verifyOpts.Intermediates = append(intermediates, chain[1:]...)
+	}
+
+	chains, err := cert.VerifyChains(crt, &verifyOpts)
+	if err != nil && aiaErr != nil {
+		return nil, fmt.Errorf("%w, %v", err, aiaErr)
+	}
+
+	return chains, err
+}
+
+// verifyInputs are the parsed files of verify flags
+type verifyInputs struct {
+	roots     []*x509.Certificate
+	untrusted []*x509.Certificate
+	key       crypto.Signer
+	csr       *x509.CertificateRequest
+	crls      []*cert.RevocationList
+}
+
+// readVerifyInputs reads and parses the files of verify flags before any
+// check, so that an unreadable input exits with 1 instead of failing a check
+func readVerifyInputs() (*verifyInputs, error) {
+	inputs := &verifyInputs{}
+
+	if crtCAFile != "" {
+		caBytes, err := readInput(crtCAFile, crtInform, cert.CertBlockType)
+		if err != nil {
+			return nil, err
+		}
+		inputs.roots, err = cert.ParseCerts(caBytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse CA certificate: %w", err)
+		}
+	}
+
+	if crtUntrust != "" {
+		untrustedBytes, err := readInput(crtUntrust, crtInform, cert.CertBlockType)
+		if err != nil {
+			return nil, err
+		}
+		inputs.untrusted, err = cert.ParseCerts(untrustedBytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse intermediate certificates: %w", err)
 		}
 	}
 
 	if crtKeyFile != "" {
-		keyBytes, err := os.ReadFile(crtKeyFile)
+		passphrase, err := readPassphrase(crtPassFile, crtPassEnv)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		inputs.key, err = readSigner(crtKeyFile, crtInform, passphrase)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		if _, err := tls.X509KeyPair(certBytes, keyBytes); err != nil {
-			return err
-		} else {
-			fmt.Println("Verified OK: the certificate matches private key")
+	if crtCSRFile != "" {
+		csrBytes, err := readInput(crtCSRFile, crtInform, cert.CertReqBlockType)
+		if err != nil {
+			return nil, err
+		}
+		inputs.csr, err = cert.ParseCertRequest(csrBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range crtCRLFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		crls, err := cert.ParseCRLs(data, file)
+		if err != nil {
+			return nil, err
 		}
+		inputs.crls = append(inputs.crls, crls...)
+	}
+
+	return inputs, nil
+}
+
+// runVerifyCSR verifies the certificate request with the private key when
+// --cert is not provided
+func runVerifyCSR(output string) error {
+	if crtKeyFile == "" || crtCSRFile == "" {
+		return fmt.Errorf("unable to verify, please provide --cert, or both --key and --csr")
+	}
+	if crtCAFile != "" || crtSysRoots || crtUntrust != "" || crtAIA || crtHost != "" || crtIP != "" || crtPurpose != "" ||
+		crtAt != "" || crtIn != "" || len(crtCRLFiles) > 0 || crtCRLFetch || crtOCSP || crtOCSPURL != "" {
+		return fmt.Errorf("only --key and --csr can be verified without --cert")
+	}
+
+	inputs, err := readVerifyInputs()
+	if err != nil {
+		return &ExitError{Code: cert.ExitError, Err: err}
+	}
+
+	csrCheck := verifyCSR(inputs.csr, nil, inputs.key)
+
+	result := &cert.VerifyResult{CSR: csrCheck}
+	result.Checks = append(result.Checks, reportCSR(csrCheck))
+
+	return printVerifyResult(result, output)
+}
+
+// verifyCSR checks the signature of the CSR and matches it with the private
+// key and the certificate if not nil
+func verifyCSR(csr *x509.CertificateRequest, crt *x509.Certificate, key crypto.Signer) *cert.CSRCheck {
+	check := cert.CheckCertificateRequest(csr, crt)
+	if key != nil {
+		check.Key = cert.NewVerifyCheck(verifyKey(csr.PublicKey, key))
+	}
+
+	return check
+}
+
+func verifyKey(pub crypto.PublicKey, key crypto.Signer) error {
+	if !cert.PublicKeyEqual(pub, key.Public()) {
+		return fmt.Errorf("private key does not match public key")
 	}
 
 	return nil
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chenzhiwei/certctl/pkg/cert"
+)
+
+// writeVerifyFiles writes the CA, leaf certificate, keys and CSR to dir and
+// returns the files by name
+func writeVerifyFiles(t *testing.T, dir string) map[string]string {
+	keyAlg, _ := cert.NewKeyAlgorithm(cert.KeyTypeECDSA, 0, "P-256")
+
+	caInfo, _ := cert.NewCertInfo(time.Hour, "CN=Test CA", "", "", "", true)
+	caBytes, caKeyBytes, err := cert.NewCertKey(caInfo, keyAlg)
+	if err != nil {
+		t.Fatalf("failed NewCertKey: %v", err)
+	}
+	otherCABytes, _, err := cert.NewCertKey(caInfo, keyAlg)
+	if err != nil {
+		t.Fatalf("failed NewCertKey: %v", err)
+	}
+	caCert, _ := cert.ParseCert(caBytes)
+	caKey, _ := cert.ParseSigner(caKeyBytes, nil)
+
+	leafInfo, _ := cert.NewCertInfo(time.Hour, "CN=leaf.com", "leaf.com", "", "", false)
+	leafBytes, keyBytes, err := cert.NewSignedCertKey(caCert, caKey, leafInfo, keyAlg)
+	if err != nil {
+		t.Fatalf("failed NewSignedCertKey: %v", err)
+	}
+	key, _ := cert.ParseSigner(keyBytes, nil)
+	encryptedKeyBytes, err := cert.EncodeEncryptedKey(key, []byte("secret"), "")
+	if err != nil {
+		t.Fatalf("failed EncodeEncryptedKey: %v", err)
+	}
+	csrBytes, err := cert.NewCertRequest(leafInfo, key)
+	if err != nil {
+		t.Fatalf("failed NewCertRequest: %v", err)
+	}
+
+	files := map[string][]byte{
+		"ca.crt":        caBytes,
+		"other-ca.crt":  otherCABytes,
+		"leaf.crt":      leafBytes,
+		"leaf.key":      keyBytes,
+		"ca.key":        caKeyBytes,
+		"encrypted.key": encryptedKeyBytes,
+		"leaf.csr":      csrBytes,
+		"junk.crt":      []byte("junk"),
+	}
+	paths := map[string]string{"missing": filepath.Join(dir, "missing")}
+	for name, data := range files {
+		paths[name] = filepath.Join(dir, name)
+		if err := os.WriteFile(paths[name], data, 0600); err != nil {
+			t.Fatalf("failed WriteFile: %v", err)
+		}
+	}
+
+	return paths
+}
+
+func TestRunVerifyExitCode(t *testing.T) {
+	files := writeVerifyFiles(t, t.TempDir())
+
+	var tests = []struct {
+		name   string
+		ca     string
+		untrst string
+		key    string
+		csr    string
+		expect int
+	}{
+		{name: "passed", ca: "ca.crt", key: "leaf.key", csr: "leaf.csr", expect: cert.ExitOK},
+		{name: "nothing to verify", expect: cert.ExitError},
+		{name: "other ca", ca: "other-ca.crt", expect: cert.ExitChain},
+		{name: "mismatched key", key: "ca.key", expect: cert.ExitMismatch},
+		{name: "missing ca", ca: "missing", expect: cert.ExitError},
+		{name: "junk ca", ca: "junk.crt", expect: cert.ExitError},
+		{name: "missing untrusted", ca: "ca.crt", untrst: "missing", expect: cert.ExitError},
+		{name: "missing key", key: "missing", expect: cert.ExitError},
+		{name: "encrypted key", key: "encrypted.key", expect: cert.ExitError},
+		{name: "missing csr", csr: "missing", expect: cert.ExitError},
+		{name: "missing key with mismatched ca", ca: "other-ca.crt", key: "missing", expect: cert.ExitError},
+	}
+
+	defer func() {
+		crtCertFile, crtCAFile, crtUntrust, crtKeyFile, crtCSRFile = "", "", "", "", ""
+	}()
+
+	for _, test := range tests {
+		crtCertFile = files["leaf.crt"]
+		crtCAFile, crtUntrust, crtKeyFile, crtCSRFile = files[test.ca], files[test.untrst], files[test.key], files[test.csr]
+
+		err := runVerify()
+
+		actual := cert.ExitOK
+		if err != nil {
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) {
+				t.Errorf("failed runVerify %s: not an ExitError: %v", test.name, err)
+				continue
+			}
+			actual = exitErr.Code
+		}
+		if actual != test.expect {
+			t.Errorf("failed runVerify %s exit code:\n\tactual: %d %v\n\texpect: %d\n", test.name, actual, err, test.expect)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// the max number of issuers fetched for one chain, to stop the loops of
+// cross-signed certificates
+const maxAIADepth = 10
+
+// the certificates fetched from the CA Issuers URLs, by URL
+var aiaCache = struct {
+	sync.Mutex
+	certs map[string][]*x509.Certificate
+}{certs: map[string][]*x509.Certificate{}}
+
+// FetchIssuers fetches the certificates from the HTTP CA Issuers URLs of AIA
+// extension, in DER, PEM or PKCS#7 format. The fetched certificates are
+// cached by URL.
+func FetchIssuers(cert *x509.Certificate) ([]*x509.Certificate, error) {
+	var issuers []*x509.Certificate
+	var errs []string
+	for _, url := range cert.IssuingCertificateURL {
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			continue
+		}
+
+		aiaCache.Lock()
+		certs, ok := aiaCache.certs[url]
+		aiaCache.Unlock()
+		if !ok {
+			data, err := fetchURL(url)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			certs, err = ParseCerts(data)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("Failed to parse certificate from %s", url))
+				continue
+			}
+
+			aiaCache.Lock()
+			aiaCache.certs[url] = certs
+			aiaCache.Unlock()
+		}
+		issuers = append(issuers, certs...)
+	}
+
+	if len(issuers) == 0 {
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("%s", strings.Join(errs, ", "))
+		}
+		return nil, fmt.Errorf("the certificate %s has no CA Issuers URL", cert.Subject.String())
+	}
+
+	return issuers, nil
+}
+
+// CompleteChain orders the certificates from the leaf, which is the first
+// one, and fetches the missing issuers from the CA Issuers URLs of AIA
+// extension, until a self-signed certificate or a certificate issued by the
+// Roots or the system roots of opts. The trusted roots are not added to the
+// chain. The incomplete chain is returned with the error if an issuer can not
+// be found.
+func CompleteChain(certs []*x509.Certificate, opts *VerifyOptions) ([]*x509.Certificate, error) {
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate to complete the chain")
+	}
+
+	// the trusted roots of the chain, regardless of the validity and purpose
+	trusted := &VerifyOptions{
+		Roots:          opts.Roots,
+		SystemRoots:    opts.SystemRoots,
+		CurrentTime:    opts.CurrentTime,
+		IgnoreValidity: true,
+	}
+
+	chain := []*x509.Certificate{certs[0]}
+	fetched := 0
+	for {
+		current := chain[len(chain)-1]
+		if IsIssuedBy(current, current) {
+			return chain, nil
+		}
+		if len(opts.Roots) > 0 || opts.SystemRoots {
+			if _, err := VerifyChains(current, trusted); err == nil {
+				return chain, nil
+			}
+		}
+
+		issuer := findIssuer(current, certs[1:])
+		if issuer == nil {
+			if fetched == maxAIADepth {
+				return chain, fmt.Errorf("unable to complete the chain, more than %d issuers fetched", maxAIADepth)
+			}
+
+			candidates, err := FetchIssuers(current)
+			if err != nil {
+				return chain, fmt.Errorf("unable to fetch the issuer %s: %w", current.Issuer.String(), err)
+			}
+			issuer = findIssuer(current, candidates)
+			if issuer == nil {
+				return chain, fmt.Errorf("unable to fetch the issuer %s: the fetched certificates do not issue %s", current.Issuer.String(), current.Subject.String())
+			}
+			fetched++
+		}
+
+		for _, c := range chain {
+			if bytes.Equal(c.Raw, issuer.Raw) {
+				return chain, fmt.Errorf("unable to complete the chain, it loops at %s", issuer.Subject.String())
+			}
+		}
+		chain = append(chain, issuer)
+	}
+}
+
+// findIssuer returns the certificate which issues cert, or nil
+func findIssuer(cert *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	for _, c := range candidates {
+		if IsIssuedBy(cert, c) {
+			return c
+		}
+	}
+
+	return nil
+}
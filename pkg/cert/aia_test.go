@@ -0,0 +1,78 @@
+package cert
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompleteChain(t *testing.T) {
+	served := map[string][]byte{}
+	hits := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := served[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		hits[r.URL.Path]++
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	root, rootKey := newTestCert(t, nil, nil, testCertOptions{subject: "CN=Root CA", isCA: true})
+	inter, interKey := newTestCert(t, root, rootKey, testCertOptions{subject: "CN=Inter CA", isCA: true, aiaURL: server.URL + "/root.der"})
+	// the same name as the intermediate but another key
+	other, _ := newTestCert(t, nil, nil, testCertOptions{subject: "CN=Inter CA", isCA: true})
+	p7c, _ := EncodePKCS7Certs([]*x509.Certificate{inter})
+
+	served["/root.der"] = root.Raw
+	served["/inter.der"] = inter.Raw
+	served["/inter.pem"] = EncodeCerts([]*x509.Certificate{inter})
+	served["/inter.p7c"] = p7c
+	served["/junk"] = []byte("junk")
+	served["/other.der"] = other.Raw
+
+	var tests = []struct {
+		name   string
+		url    string
+		extra  []*x509.Certificate
+		roots  []*x509.Certificate
+		length int
+		errMsg string
+	}{
+		{name: "der", url: "/inter.der", length: 3},
+		{name: "pem", url: "/inter.pem", length: 3},
+		{name: "pkcs7", url: "/inter.p7c", length: 3},
+		{name: "trusted root", url: "/inter.der", roots: []*x509.Certificate{root}, length: 2},
+		{name: "sent intermediate", url: "/missing", extra: []*x509.Certificate{root, inter}, length: 3},
+		{name: "not found", url: "/missing", length: 1, errMsg: "404 Not Found"},
+		{name: "junk", url: "/junk", length: 1, errMsg: "Failed to parse certificate"},
+		{name: "other issuer", url: "/other.der", length: 1, errMsg: "the fetched certificates do not issue"},
+		{name: "no url", length: 1, errMsg: "has no CA Issuers URL"},
+	}
+
+	for _, test := range tests {
+		url := ""
+		if test.url != "" {
+			url = server.URL + test.url
+		}
+		leaf, _ := newTestCert(t, inter, interKey, testCertOptions{subject: "CN=leaf.com", aiaURL: url})
+
+		chain, err := CompleteChain(append([]*x509.Certificate{leaf}, test.extra...), &VerifyOptions{Roots: test.roots})
+		if len(chain) != test.length || (err != nil) != (test.errMsg != "") || (err != nil && !strings.Contains(err.Error(), test.errMsg)) {
+			t.Errorf("failed CompleteChain %s:\n\tactual: %d %v\n\texpect: %d %s\n", test.name, len(chain), err, test.length, test.errMsg)
+			continue
+		}
+		if err == nil && !chain[1].Equal(inter) {
+			t.Errorf("failed CompleteChain %s: the issuer is %s", test.name, chain[1].Subject.String())
+		}
+	}
+
+	// the issuers are fetched once
+	if hits["/root.der"] != 1 || hits["/inter.der"] != 1 {
+		t.Errorf("failed CompleteChain cache: %v", hits)
+	}
+}
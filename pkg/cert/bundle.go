@@ -0,0 +1,201 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+)
+
+const CRLBlockType = "X509 CRL"
+
+// BundleEntry is one PEM block of a mixed PEM bundle, only one of Cert, CSR,
+// Key, PublicKey and CRL is set, or Err if the block can not be parsed
+type BundleEntry struct {
+	Block     int
+	Type      string
+	Cert      *x509.Certificate
+	CSR       *x509.CertificateRequest
+	Key       crypto.Signer
+	PublicKey crypto.PublicKey
+	CRL       *x509.RevocationList
+	Err       error
+}
+
+// KeyMatch is a private key and the certificate it matches, the numbers are
+// the 1-based PEM block numbers
+type KeyMatch struct {
+	Key  int `json:"key" yaml:"key"`
+	Cert int `json:"cert" yaml:"cert"`
+}
+
+// CRLInfo is the machine-readable info of certificate revocation list
+type CRLInfo struct {
+	Issuer             Name      `json:"issuer" yaml:"issuer"`
+	Number             string    `json:"number,omitempty" yaml:"number,omitempty"`
+	ThisUpdate         time.Time `json:"thisUpdate" yaml:"thisUpdate"`
+	NextUpdate         time.Time `json:"nextUpdate,omitempty" yaml:"nextUpdate,omitempty"`
+	RevokedCount       int       `json:"revokedCount" yaml:"revokedCount"`
+	SignatureAlgorithm string    `json:"signatureAlgorithm" yaml:"signatureAlgorithm"`
+}
+
+// BundleEntryInfo is the machine-readable info of one PEM block
+type BundleEntryInfo struct {
+	Block              int                     `json:"block" yaml:"block"`
+	Type               string                  `json:"type" yaml:"type"`
+	Certificate        *CertificateInfo        `json:"certificate,omitempty" yaml:"certificate,omitempty"`
+	CertificateRequest *CertificateRequestInfo `json:"certificateRequest,omitempty" yaml:"certificateRequest,omitempty"`
+	Key                *KeyInfo                `json:"key,omitempty" yaml:"key,omitempty"`
+	CRL                *CRLInfo                `json:"crl,omitempty" yaml:"crl,omitempty"`
+	Error              string                  `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// BundleInfo is the machine-readable info of mixed PEM bundle
+type BundleInfo struct {
+	Entries    []*BundleEntryInfo `json:"entries" yaml:"entries"`
+	KeyMatches []KeyMatch         `json:"keyMatches,omitempty" yaml:"keyMatches,omitempty"`
+}
+
+// IsMixedBundle returns true if the PEM data contains blocks other than
+// certificates, or more than one certificate request or key, the EC
+// PARAMETERS block is ignored
+func IsMixedBundle(pemBytes []byte) bool {
+	counts := map[string]int{}
+	rest := pemBytes
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == ECParamsBlockType {
+			continue
+		}
+		if IsKeyBlockType(block.Type) {
+			counts["KEY"]++
+		} else {
+			counts[block.Type]++
+		}
+	}
+
+	switch {
+	case len(counts) == 0:
+		return false
+	case len(counts) > 1:
+		return true
+	case counts[CertBlockType] > 0:
+		return false
+	case counts[CertReqBlockType] == 1, counts["KEY"] == 1:
+		return false
+	}
+
+	return true
+}
+
+// ParseBundle parses every PEM block of the mixed PEM bundle, the passphrase
+// is only used for encrypted private keys. The blocks that can not be parsed
+// are returned with Err set.
+func ParseBundle(pemBytes, passphrase []byte) ([]*BundleEntry, error) {
+	var entries []*BundleEntry
+
+	rest := pemBytes
+	for i := 1; ; i++ {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		entry := &BundleEntry{Block: i, Type: block.Type}
+		switch {
+		case block.Type == CertBlockType:
+			entry.Cert, entry.Err = x509.ParseCertificate(block.Bytes)
+		case block.Type == CertReqBlockType:
+			entry.CSR, entry.Err = x509.ParseCertificateRequest(block.Bytes)
+		case block.Type == CRLBlockType:
+			entry.CRL, entry.Err = x509.ParseRevocationList(block.Bytes)
+		case block.Type == PublicKeyBlockType || block.Type == RSAPublicKeyBlockType:
+			entry.PublicKey, entry.Err = ParsePublicKey(pem.EncodeToMemory(block))
+		case IsKeyBlockType(block.Type):
+			entry.Key, entry.Err = ParseSigner(pem.EncodeToMemory(block), passphrase)
+		case block.Type == ECParamsBlockType:
+			continue
+		default:
+			entry.Err = fmt.Errorf("Unsupported type: %s", block.Type)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("Failed to decode PEM data")
+	}
+
+	return entries, nil
+}
+
+// GetKeyMatches returns the private keys and the certificates they match
+func GetKeyMatches(entries []*BundleEntry) []KeyMatch {
+	var matches []KeyMatch
+	for _, key := range entries {
+		if key.Key == nil {
+			continue
+		}
+		for _, crt := range entries {
+			if crt.Cert != nil && PublicKeyEqual(crt.Cert.PublicKey, key.Key.Public()) {
+				matches = append(matches, KeyMatch{Key: key.Block, Cert: crt.Block})
+			}
+		}
+	}
+
+	return matches
+}
+
+// NewCRLInfo returns the machine-readable info of certificate revocation list
+func NewCRLInfo(crl *x509.RevocationList) *CRLInfo {
+	info := &CRLInfo{
+		Issuer:             newName(crl.Issuer),
+		ThisUpdate:         crl.ThisUpdate,
+		NextUpdate:         crl.NextUpdate,
+		RevokedCount:       len(crl.RevokedCertificateEntries),
+		SignatureAlgorithm: crl.SignatureAlgorithm.String(),
+	}
+	if crl.Number != nil {
+		info.Number = formatSerial(crl.Number)
+	}
+
+	return info
+}
+
+// NewBundleInfo returns the machine-readable info of mixed PEM bundle
+func NewBundleInfo(entries []*BundleEntry) *BundleInfo {
+	info := &BundleInfo{KeyMatches: GetKeyMatches(entries)}
+
+	for _, entry := range entries {
+		entryInfo := &BundleEntryInfo{Block: entry.Block, Type: entry.Type}
+
+		var err error
+		switch {
+		case entry.Err != nil:
+			err = entry.Err
+		case entry.Cert != nil:
+			entryInfo.Certificate = NewCertificateInfo(entry.Cert)
+		case entry.CSR != nil:
+			entryInfo.CertificateRequest = NewCertificateRequestInfo(entry.CSR)
+		case entry.CRL != nil:
+			entryInfo.CRL = NewCRLInfo(entry.CRL)
+		case entry.Key != nil:
+			entryInfo.Key, err = NewPrivateKeyInfo(entry.Key)
+		case entry.PublicKey != nil:
+			entryInfo.Key, err = NewPublicKeyInfo(entry.PublicKey)
+		}
+		if err != nil {
+			entryInfo.Error = err.Error()
+		}
+
+		info.Entries = append(info.Entries, entryInfo)
+	}
+
+	return info
+}
@@ -0,0 +1,134 @@
+package cert
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIsMixedBundle(t *testing.T) {
+	block := func(blockType string) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: []byte{0}})
+	}
+	join := func(blocks ...[]byte) []byte {
+		var data []byte
+		for _, b := range blocks {
+			data = append(data, b...)
+		}
+		return data
+	}
+
+	var tests = []struct {
+		name   string
+		data   []byte
+		expect bool
+	}{
+		{name: "certs", data: join(block(CertBlockType), block(CertBlockType)), expect: false},
+		{name: "csr", data: block(CertReqBlockType), expect: false},
+		{name: "key", data: block(RSAKeyBlockType), expect: false},
+		{name: "ec key", data: join(block(ECParamsBlockType), block(ECKEYBlockType)), expect: false},
+		{name: "key and cert", data: join(block(PrivateKeyBlockType), block(CertBlockType)), expect: true},
+		{name: "two keys", data: join(block(PrivateKeyBlockType), block(PublicKeyBlockType)), expect: true},
+		{name: "crl", data: block(CRLBlockType), expect: true},
+		{name: "not pem", data: []byte("hello"), expect: false},
+	}
+
+	for _, test := range tests {
+		if actual := IsMixedBundle(test.data); actual != test.expect {
+			t.Errorf("failed IsMixedBundle %s:\n\tactual: %v\n\texpect: %v\n", test.name, actual, test.expect)
+		}
+	}
+}
+
+func TestParseBundle(t *testing.T) {
+	keyAlg, _ := NewKeyAlgorithm(KeyTypeECDSA, 0, "P-256")
+	key, err := keyAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+	otherKey, err := keyAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+
+	certInfo, err := NewCertInfo(time.Hour, "CN=china", "", "certSign,cRLSign", "", true)
+	if err != nil {
+		t.Fatalf("failed NewCertInfo: %v", err)
+	}
+	certBytes, err := NewCert(certInfo, key)
+	if err != nil {
+		t.Fatalf("failed NewCert: %v", err)
+	}
+	otherCertBytes, err := NewCert(certInfo, otherKey)
+	if err != nil {
+		t.Fatalf("failed NewCert: %v", err)
+	}
+	keyBytes, err := EncodeKey(key)
+	if err != nil {
+		t.Fatalf("failed EncodeKey: %v", err)
+	}
+
+	issuer, _ := ParseCert(certBytes)
+	crlDER, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(7),
+		ThisUpdate: time.Now(),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: big.NewInt(1), RevocationTime: time.Now()},
+		},
+	}, issuer, key)
+	if err != nil {
+		t.Fatalf("failed CreateRevocationList: %v", err)
+	}
+
+	var data []byte
+	data = append(data, otherCertBytes...)
+	data = append(data, keyBytes...)
+	data = append(data, certBytes...)
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: CRLBlockType, Bytes: crlDER})...)
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "UNKNOWN", Bytes: []byte{0}})...)
+
+	entries, err := ParseBundle(data, nil)
+	if err != nil {
+		t.Fatalf("failed ParseBundle: %v", err)
+	}
+
+	var types []string
+	for _, entry := range entries {
+		types = append(types, entry.Type)
+	}
+	expectTypes := []string{CertBlockType, ECKEYBlockType, CertBlockType, CRLBlockType, "UNKNOWN"}
+	if !slices.Equal(types, expectTypes) {
+		t.Fatalf("failed ParseBundle:\n\tactual: %v\n\texpect: %v\n", types, expectTypes)
+	}
+	if entries[1].Key == nil || entries[3].CRL == nil || entries[4].Err == nil {
+		t.Errorf("failed ParseBundle: %+v %+v %+v", entries[1], entries[3], entries[4])
+	}
+
+	matches := GetKeyMatches(entries)
+	expectMatches := []KeyMatch{{Key: 2, Cert: 3}}
+	if !slices.Equal(matches, expectMatches) {
+		t.Errorf("failed GetKeyMatches:\n\tactual: %v\n\texpect: %v\n", matches, expectMatches)
+	}
+
+	info := NewBundleInfo(entries)
+	if info.Entries[3].CRL == nil || info.Entries[3].CRL.RevokedCount != 1 || info.Entries[3].CRL.Number != "07" {
+		t.Errorf("failed NewBundleInfo.CRL: %+v", info.Entries[3].CRL)
+	}
+	if info.Entries[4].Error == "" {
+		t.Errorf("failed NewBundleInfo: expect error of unknown block")
+	}
+
+	// the certificates and key of combined PEM file can be parsed directly
+	certs, err := ParseCerts(data)
+	if err != nil || len(certs) != 2 {
+		t.Errorf("failed ParseCerts combined file: %d %v", len(certs), err)
+	}
+	if _, err := ParseSigner(data, nil); err != nil {
+		t.Errorf("failed ParseSigner combined file: %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// ChainCert is a certificate of the bundle and its relationship in the chain,
+// the position is the 1-based position in the bundle
+type ChainCert struct {
+	Position    int    `json:"position" yaml:"position"`
+	Subject     string `json:"subject" yaml:"subject"`
+	Issuer      string `json:"issuer" yaml:"issuer"`
+	SelfSigned  bool   `json:"selfSigned" yaml:"selfSigned"`
+	OutOfOrder  bool   `json:"outOfOrder,omitempty" yaml:"outOfOrder,omitempty"`
+	DuplicateOf int    `json:"duplicateOf,omitempty" yaml:"duplicateOf,omitempty"`
+}
+
+// ChainInfo is the certificate chain built from the bundle, the chain starts
+// from the leaf to the root or the last certificate whose issuer is missing
+type ChainInfo struct {
+	Chain         []*ChainCert `json:"chain" yaml:"chain"`
+	Complete      bool         `json:"complete" yaml:"complete"`
+	MissingIssuer string       `json:"missingIssuer,omitempty" yaml:"missingIssuer,omitempty"`
+	Unrelated     []*ChainCert `json:"unrelated,omitempty" yaml:"unrelated,omitempty"`
+	Duplicates    []*ChainCert `json:"duplicates,omitempty" yaml:"duplicates,omitempty"`
+}
+
+// NewChainInfo links every certificate to its issuer by name, AKI/SKI and
+// signature, and builds the chain from the leaf. Every certificate that does
+// not issue any other certificate of the bundle is a candidate leaf, the one
+// with the longest chain is the leaf, or the first one if they are equal.
+func NewChainInfo(certs []*x509.Certificate) *ChainInfo {
+	info := &ChainInfo{}
+
+	// the later copies of the same certificate are ignored
+	var unique []int
+	for i, cert := range certs {
+		duplicate := false
+		for _, j := range unique {
+			if bytes.Equal(cert.Raw, certs[j].Raw) {
+				info.Duplicates = append(info.Duplicates, newChainCert(certs, i, j+1))
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			unique = append(unique, i)
+		}
+	}
+
+	issuerOf := map[int]int{}
+	isIssuer := map[int]bool{}
+	for _, i := range unique {
+		for _, j := range unique {
+			if i != j && IsIssuedBy(certs[i], certs[j]) {
+				issuerOf[i] = j
+				isIssuer[j] = true
+				break
+			}
+		}
+	}
+
+	if len(unique) == 0 {
+		return info
+	}
+
+	var chain []int
+	for _, leaf := range unique {
+		if isIssuer[leaf] {
+			continue
+		}
+		if candidate := buildChain(leaf, issuerOf); len(candidate) > len(chain) {
+			chain = candidate
+		}
+	}
+	if chain == nil {
+		// every certificate issues another one, like cross-signed loops
+		chain = buildChain(unique[0], issuerOf)
+	}
+
+	inChain := map[int]bool{}
+	for _, i := range chain {
+		inChain[i] = true
+
+		chainCert := newChainCert(certs, i, 0)
+		if n := len(info.Chain); n > 0 && chainCert.Position < info.Chain[n-1].Position {
+			// the issuer appears before the certificate it issued
+			chainCert.OutOfOrder = true
+		}
+		info.Chain = append(info.Chain, chainCert)
+
+		if _, found := issuerOf[i]; !found {
+			info.Complete = chainCert.SelfSigned
+			if !chainCert.SelfSigned {
+				info.MissingIssuer = certs[i].Issuer.String()
+			}
+		}
+	}
+	if len(info.Chain) > 0 && info.Chain[0].Position != 1 {
+		info.Chain[0].OutOfOrder = true
+	}
+
+	for _, i := range unique {
+		if !inChain[i] {
+			info.Unrelated = append(info.Unrelated, newChainCert(certs, i, 0))
+		}
+	}
+
+	return info
+}
+
+// buildChain follows the issuers from leaf until the root, a missing issuer
+// or a loop, and returns the indexes of the chain
+func buildChain(leaf int, issuerOf map[int]int) []int {
+	var chain []int
+	seen := map[int]bool{}
+	for i, ok := leaf, true; ok && !seen[i]; i, ok = issuerOf[i] {
+		seen[i] = true
+		chain = append(chain, i)
+	}
+
+	return chain
+}
+
+// IsIssuedBy returns true if the issuer name, AKI/SKI and signature of cert
+// match the issuer certificate
+func IsIssuedBy(cert, issuer *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+		return false
+	}
+	if len(cert.AuthorityKeyId) > 0 && len(issuer.SubjectKeyId) > 0 && !bytes.Equal(cert.AuthorityKeyId, issuer.SubjectKeyId) {
+		return false
+	}
+
+	// not CheckSignatureFrom, the basic constraints are not checked here
+	return issuer.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// GetChainText returns the chain as an indented tree from leaf to root
+func GetChainText(info *ChainInfo) string {
+	var b strings.Builder
+
+	for i, c := range info.Chain {
+		prefix := ""
+		if i > 0 {
+			prefix = strings.Repeat("    ", i-1) + "└── "
+		}
+
+		var flags []string
+		if c.SelfSigned {
+			flags = append(flags, "root")
+		}
+		if c.OutOfOrder {
+			flags = append(flags, "out of order")
+		}
+		fmt.Fprintf(&b, "%s%s\n", prefix, formatChainCert(c, flags))
+	}
+
+	if info.MissingIssuer != "" {
+		prefix := strings.Repeat("    ", len(info.Chain)-1) + "└── "
+		fmt.Fprintf(&b, "%s[?] %s (missing, the chain is incomplete)\n", prefix, info.MissingIssuer)
+	}
+
+	if len(info.Unrelated) > 0 {
+		fmt.Fprintf(&b, "\nNot part of the chain:\n")
+		for _, c := range info.Unrelated {
+			fmt.Fprintf(&b, "%s\n", formatChainCert(c, nil))
+		}
+	}
+
+	if len(info.Duplicates) > 0 {
+		fmt.Fprintf(&b, "\nDuplicated:\n")
+		for _, c := range info.Duplicates {
+			fmt.Fprintf(&b, "%s\n", formatChainCert(c, []string{fmt.Sprintf("same as [%d]", c.DuplicateOf)}))
+		}
+	}
+
+	return b.String()
+}
+
+func newChainCert(certs []*x509.Certificate, i, duplicateOf int) *ChainCert {
+	return &ChainCert{
+		Position:    i + 1,
+		Subject:     certs[i].Subject.String(),
+		Issuer:      certs[i].Issuer.String(),
+		SelfSigned:  IsIssuedBy(certs[i], certs[i]),
+		DuplicateOf: duplicateOf,
+	}
+}
+
+func formatChainCert(c *ChainCert, flags []string) string {
+	s := fmt.Sprintf("[%d] %s", c.Position, c.Subject)
+	if len(flags) > 0 {
+		s += " (" + strings.Join(flags, ", ") + ")"
+	}
+
+	return s
+}
@@ -0,0 +1,88 @@
+package cert
+
+import (
+	"crypto/x509"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewChainInfo(t *testing.T) {
+	certs := newTestChain(t)
+	root, inter, leaf := certs[0], certs[1], certs[2]
+
+	other := newTestChain(t)[0]
+
+	var tests = []struct {
+		name       string
+		certs      []*x509.Certificate
+		chain      []int
+		outOfOrder []int
+		complete   bool
+		unrelated  []int
+		duplicates []int
+	}{
+		{name: "ordered", certs: []*x509.Certificate{leaf, inter, root}, chain: []int{1, 2, 3}, complete: true},
+		{name: "without root", certs: []*x509.Certificate{leaf, inter}, chain: []int{1, 2}},
+		{name: "reversed", certs: []*x509.Certificate{root, inter, leaf}, chain: []int{3, 2, 1}, outOfOrder: []int{3, 2, 1}, complete: true},
+		{name: "unrelated", certs: []*x509.Certificate{leaf, other, inter}, chain: []int{1, 3}, unrelated: []int{2}},
+		{name: "duplicated", certs: []*x509.Certificate{leaf, inter, inter}, chain: []int{1, 2}, duplicates: []int{3}},
+		{name: "stray leading", certs: []*x509.Certificate{other, leaf, inter, root}, chain: []int{2, 3, 4}, outOfOrder: []int{2}, complete: true, unrelated: []int{1}},
+		{name: "self-signed", certs: []*x509.Certificate{root}, chain: []int{1}, complete: true},
+	}
+
+	positions := func(cs []*ChainCert) []int {
+		var p []int
+		for _, c := range cs {
+			p = append(p, c.Position)
+		}
+		return p
+	}
+
+	for _, test := range tests {
+		info := NewChainInfo(test.certs)
+
+		if actual := positions(info.Chain); !slices.Equal(actual, test.chain) {
+			t.Errorf("failed NewChainInfo %s chain:\n\tactual: %v\n\texpect: %v\n", test.name, actual, test.chain)
+		}
+
+		var outOfOrder []int
+		for _, c := range info.Chain {
+			if c.OutOfOrder {
+				outOfOrder = append(outOfOrder, c.Position)
+			}
+		}
+		if !slices.Equal(outOfOrder, test.outOfOrder) {
+			t.Errorf("failed NewChainInfo %s out of order:\n\tactual: %v\n\texpect: %v\n", test.name, outOfOrder, test.outOfOrder)
+		}
+
+		if info.Complete != test.complete || (info.MissingIssuer == "") != test.complete {
+			t.Errorf("failed NewChainInfo %s complete:\n\tactual: %v %q\n\texpect: %v\n", test.name, info.Complete, info.MissingIssuer, test.complete)
+		}
+		if actual := positions(info.Unrelated); !slices.Equal(actual, test.unrelated) {
+			t.Errorf("failed NewChainInfo %s unrelated:\n\tactual: %v\n\texpect: %v\n", test.name, actual, test.unrelated)
+		}
+		if actual := positions(info.Duplicates); !slices.Equal(actual, test.duplicates) {
+			t.Errorf("failed NewChainInfo %s duplicates:\n\tactual: %v\n\texpect: %v\n", test.name, actual, test.duplicates)
+		}
+	}
+}
+
+func TestGetChainText(t *testing.T) {
+	certs := newTestChain(t)
+
+	text := GetChainText(NewChainInfo([]*x509.Certificate{certs[2], certs[1]}))
+	expect := "[1] CN=leaf.com\n" +
+		"└── [2] CN=Inter CA\n" +
+		"    └── [?] CN=Root CA (missing, the chain is incomplete)\n"
+	if text != expect {
+		t.Errorf("failed GetChainText:\n\tactual: %s\n\texpect: %s\n", text, expect)
+	}
+
+	text = GetChainText(NewChainInfo([]*x509.Certificate{certs[0], certs[2], certs[1], certs[1]}))
+	for _, s := range []string{"[2] CN=leaf.com (out of order)", "[1] CN=Root CA (root, out of order)", "Duplicated:", "[4] CN=Inter CA (same as [3])"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("failed GetChainText:\n\tactual: %s\n\texpect: %s\n", text, s)
+		}
+	}
+}
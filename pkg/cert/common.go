@@ -1,6 +1,7 @@
 package cert
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -63,21 +64,31 @@ type CertInfo struct {
 }
 
 func NewCertInfo(duration time.Duration, sub, san, usage, extUsage string, isCA bool) (*CertInfo, error) {
-	certInfo := &CertInfo{}
+	subject, err := getSubject(sub)
+	if err != nil {
+		return nil, err
+	}
 
-	serialNumber, err := getSerialNumber()
+	certInfo, err := newCertInfo(duration, san, usage, extUsage, isCA)
 	if err != nil {
 		return nil, err
 	}
-	certInfo.SerialNumber = serialNumber
+	certInfo.Subject = subject
 
-	certInfo.IsCA = isCA
+	return certInfo, nil
+}
 
-	subject, err := getSubject(sub)
+// newCertInfo returns the CertInfo without subject
+func newCertInfo(duration time.Duration, san, usage, extUsage string, isCA bool) (*CertInfo, error) {
+	certInfo := &CertInfo{}
+
+	serialNumber, err := getSerialNumber()
 	if err != nil {
 		return nil, err
 	}
-	certInfo.Subject = subject
+	certInfo.SerialNumber = serialNumber
+
+	certInfo.IsCA = isCA
 
 	keyUsage, err := getKeyUsage(usage)
 	if err != nil {
@@ -97,20 +108,29 @@ func NewCertInfo(duration time.Duration, sub, san, usage, extUsage string, isCA
 	return certInfo, nil
 }
 
+// ParseCerts parses the PEM, DER or PKCS#7 certificates
 func ParseCerts(certBytes []byte) ([]*x509.Certificate, error) {
+	pemBytes, err := ToPEM(certBytes, "", CertBlockType)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse certificate: %w", err)
+	}
+
+	// only the certificate blocks are parsed, the others like private key in
+	// a combined PEM file are skipped
 	var blocks []byte
-	rest := certBytes
+	rest := pemBytes
 	for {
 		var block *pem.Block
 		block, rest = pem.Decode(rest)
 		if block == nil {
-			return nil, fmt.Errorf("Failed to parse certificate")
-		}
-		blocks = append(blocks, block.Bytes...)
-
-		if len(rest) == 0 {
 			break
 		}
+		if block.Type == CertBlockType {
+			blocks = append(blocks, block.Bytes...)
+		}
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("Failed to parse certificate")
 	}
 
 	certs, err := x509.ParseCertificates(blocks)
@@ -121,8 +141,28 @@ func ParseCerts(certBytes []byte) ([]*x509.Certificate, error) {
 	return certs, nil
 }
 
+// EncodeCerts encodes the certificates to PEM
+func EncodeCerts(certs []*x509.Certificate) []byte {
+	var buf bytes.Buffer
+	for _, cert := range certs {
+		buf.Write(pem.EncodeToMemory(&pem.Block{Type: CertBlockType, Bytes: cert.Raw}))
+	}
+
+	return buf.Bytes()
+}
+
+// ParseCert parses the first PEM, DER or PKCS#7 certificate
 func ParseCert(certBytes []byte) (*x509.Certificate, error) {
-	block, _ := pem.Decode(certBytes)
+	pemBytes, err := ToPEM(certBytes, "", CertBlockType)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse certificate: %w", err)
+	}
+
+	// skip the leading non-certificate blocks of a combined PEM file
+	block, rest := pem.Decode(pemBytes)
+	for block != nil && block.Type != CertBlockType {
+		block, rest = pem.Decode(rest)
+	}
 	if block == nil {
 		return nil, fmt.Errorf("Failed to parse certificate")
 	}
@@ -136,29 +176,73 @@ func ParseCert(certBytes []byte) (*x509.Certificate, error) {
 }
 
 func ParseKey(keyBytes []byte) (interface{}, error) {
-	block, _ := pem.Decode(keyBytes)
+	return ParseKeyWithPassphrase(keyBytes, nil)
+}
+
+// ParseKeyWithPassphrase is the same as ParseKey, but it can decrypt the
+// encrypted PKCS#8 private key and the legacy OpenSSL encrypted PEM private key.
+// The private key can be PEM or DER.
+func ParseKeyWithPassphrase(keyBytes, passphrase []byte) (interface{}, error) {
+	blockType, der, err := decodePrivateKey(keyBytes, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parsePrivateKeyDER(blockType, der)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse public key: %w", err)
+	}
+
+	return key, nil
+}
+
+// decodePrivateKey returns the PEM block type and the decrypted DER bytes of
+// the private key
+func decodePrivateKey(keyBytes, passphrase []byte) (string, []byte, error) {
+	pemBytes, err := ToPEM(keyBytes, "", PrivateKeyBlockType)
+	if err != nil {
+		return "", nil, fmt.Errorf("Failed to parse private key: %w", err)
+	}
+
+	block := decodeKeyBlock(pemBytes)
 	if block == nil {
-		return nil, fmt.Errorf("Failed to parse private key")
+		return "", nil, fmt.Errorf("Failed to parse private key")
+	}
+
+	der := block.Bytes
+	encrypted := block.Type == EncryptedPrivateKeyBlockType || x509.IsEncryptedPEMBlock(block)
+	if encrypted && len(passphrase) == 0 {
+		return "", nil, ErrPassphraseRequired
+	}
+
+	if block.Type == EncryptedPrivateKeyBlockType {
+		der, err = DecryptPKCS8PrivateKey(block.Bytes, passphrase)
+		// the decrypted key is PKCS#8
+		block.Type = PrivateKeyBlockType
+	} else if x509.IsEncryptedPEMBlock(block) {
+		// Proc-Type: 4,ENCRYPTED
+		der, err = x509.DecryptPEMBlock(block, passphrase)
 	}
+	if err != nil {
+		return "", nil, fmt.Errorf("Failed to decrypt private key: %w", err)
+	}
+
+	return block.Type, der, nil
+}
 
-	var key interface{}
-	var err error
-	if block.Type == RSAKeyBlockType {
+// parsePrivateKeyDER parses the DER private key by its PEM block type
+func parsePrivateKeyDER(blockType string, der []byte) (interface{}, error) {
+	if blockType == RSAKeyBlockType {
 		// Public-Key Cryptography Standard
 		// for RSA only
-		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
-	} else if block.Type == ECKEYBlockType {
+		return x509.ParsePKCS1PrivateKey(der)
+	} else if blockType == ECKEYBlockType {
 		// for EC only
-		key, err = x509.ParseECPrivateKey(block.Bytes)
-	} else {
-		// for all algorithms
-		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
-	}
-	if err != nil {
-		return nil, fmt.Errorf("Failed to parse public key: %w", err)
+		return x509.ParseECPrivateKey(der)
 	}
 
-	return key, nil
+	// for all algorithms
+	return x509.ParsePKCS8PrivateKey(der)
 }
 
 func appendEKU(ekus []x509.ExtKeyUsage, eku x509.ExtKeyUsage) []x509.ExtKeyUsage {
@@ -324,8 +408,8 @@ func getDNSNamesAndIPAddrs(s string) ([]string, []net.IP) {
 	hosts := strings.Split(s, ",")
 	for _, host := range hosts {
 		host = strings.TrimSpace(host)
-		host = strings.TrimLeft(host, "ip:")
-		host = strings.TrimLeft(host, "dns:")
+		host = strings.TrimPrefix(host, "ip:")
+		host = strings.TrimPrefix(host, "dns:")
 		host = strings.TrimSpace(host)
 		if host == "" {
 			continue
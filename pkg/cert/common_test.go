@@ -117,6 +117,19 @@ func TestNewCertInfo(t *testing.T) {
 				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
 			},
 		},
+		{
+			subject:  "CN=sandbox",
+			san:      "sandbox.com,DNS:dns.china.com,IP:::1,pi.china.com",
+			usage:    "",
+			extUsage: "",
+			isCA:     false,
+			expect: &CertInfo{
+				Subject:     &pkix.Name{CommonName: "sandbox"},
+				DNSNames:    []string{"sandbox.com", "dns.china.com", "pi.china.com"},
+				IPAddrs:     []net.IP{net.IPv6loopback},
+				ExtKeyUsage: []x509.ExtKeyUsage{},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,219 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RFC 5280 section 5.3.1
+var crlReasonToString = map[int]string{
+	0:  "unspecified",
+	1:  "keyCompromise",
+	2:  "cACompromise",
+	3:  "affiliationChanged",
+	4:  "superseded",
+	5:  "cessationOfOperation",
+	6:  "certificateHold",
+	8:  "removeFromCRL",
+	9:  "privilegeWithdrawn",
+	10: "aACompromise",
+}
+
+// RevocationList is the CRL and where it is loaded from, a file or URL
+type RevocationList struct {
+	Source string
+	CRL    *x509.RevocationList
+}
+
+// CRLCheck is the result of checking certificate against the CRL of issuer
+type CRLCheck struct {
+	Subject      string     `json:"subject" yaml:"subject"`
+	SerialNumber string     `json:"serialNumber" yaml:"serialNumber"`
+	CRL          string     `json:"crl,omitempty" yaml:"crl,omitempty"`
+	ThisUpdate   *time.Time `json:"thisUpdate,omitempty" yaml:"thisUpdate,omitempty"`
+	NextUpdate   *time.Time `json:"nextUpdate,omitempty" yaml:"nextUpdate,omitempty"`
+	Verified     bool       `json:"verified" yaml:"verified"`
+	Revoked      bool       `json:"revoked" yaml:"revoked"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty" yaml:"revokedAt,omitempty"`
+	Reason       string     `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Error        string     `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// ParseCRLs parses the CRLs in PEM or DER format
+func ParseCRLs(data []byte, source string) ([]*RevocationList, error) {
+	var crls []*RevocationList
+
+	if !bytes.Contains(data, []byte("-----BEGIN ")) {
+		crl, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse CRL %s: %w", source, err)
+		}
+		return []*RevocationList{{Source: source, CRL: crl}}, nil
+	}
+
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != CRLBlockType {
+			continue
+		}
+
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse CRL %s: %w", source, err)
+		}
+		crls = append(crls, &RevocationList{Source: source, CRL: crl})
+	}
+	if len(crls) == 0 {
+		return nil, fmt.Errorf("Failed to parse CRL %s: no %s block", source, CRLBlockType)
+	}
+
+	return crls, nil
+}
+
+// FetchCRL fetches the CRL from the HTTP distribution point
+func FetchCRL(url string) (*RevocationList, error) {
+	data, err := fetchURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	crls, err := ParseCRLs(data, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return crls[0], nil
+}
+
+// CheckCRLs checks each certificate of the chain, which is from the leaf to
+// the root, against the CRLs of its issuer. The CRL distribution points are
+// fetched if none of the crls is issued by the issuer and fetch is true. A
+// CRL is required for the leaf, the others are checked only if CRL is found.
+// The zero at is now.
+func CheckCRLs(chain []*x509.Certificate, crls []*RevocationList, fetch bool, at time.Time) []*CRLCheck {
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	var checks []*CRLCheck
+	fetched := map[string]*RevocationList{}
+	for i := 0; i+1 < len(chain); i++ {
+		cert, issuer := chain[i], chain[i+1]
+
+		var candidates []*RevocationList
+		for _, crl := range crls {
+			if bytes.Equal(crl.CRL.RawIssuer, cert.RawIssuer) {
+				candidates = append(candidates, crl)
+			}
+		}
+
+		var fetchErrs []string
+		if len(candidates) == 0 && fetch {
+			for _, url := range cert.CRLDistributionPoints {
+				if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+					continue
+				}
+
+				crl, ok := fetched[url]
+				if !ok {
+					var err error
+					if crl, err = FetchCRL(url); err != nil {
+						fetchErrs = append(fetchErrs, err.Error())
+						continue
+					}
+					fetched[url] = crl
+				}
+				if bytes.Equal(crl.CRL.RawIssuer, cert.RawIssuer) {
+					candidates = append(candidates, crl)
+				}
+			}
+		}
+
+		check := &CRLCheck{Subject: cert.Subject.String(), SerialNumber: formatSerial(cert.SerialNumber)}
+		switch {
+		case len(candidates) > 0:
+			checkCRL(check, cert, issuer, selectCRL(candidates, issuer), at)
+		case len(fetchErrs) > 0:
+			check.Error = strings.Join(fetchErrs, ", ")
+		case i == 0:
+			check.Error = fmt.Sprintf("no CRL found for the issuer %s", cert.Issuer.String())
+			if fetch && len(cert.CRLDistributionPoints) == 0 {
+				check.Error += ", the certificate has no CRL distribution point"
+			}
+		default:
+			continue
+		}
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// selectCRL returns the latest CRL signed by the issuer, or the latest one
+// if none is signed by the issuer
+func selectCRL(candidates []*RevocationList, issuer *x509.Certificate) *RevocationList {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].CRL.ThisUpdate.After(candidates[j].CRL.ThisUpdate)
+	})
+
+	for _, crl := range candidates {
+		if crl.CRL.CheckSignatureFrom(issuer) == nil {
+			return crl
+		}
+	}
+
+	return candidates[0]
+}
+
+// checkCRL checks the signature and freshness of CRL and looks up the serial
+// number of certificate, the revoked certificate is still reported if the
+// CRL is stale
+func checkCRL(check *CRLCheck, cert, issuer *x509.Certificate, crl *RevocationList, at time.Time) {
+	check.CRL = crl.Source
+	check.ThisUpdate = &crl.CRL.ThisUpdate
+	if !crl.CRL.NextUpdate.IsZero() {
+		check.NextUpdate = &crl.CRL.NextUpdate
+	}
+
+	if err := crl.CRL.CheckSignatureFrom(issuer); err != nil {
+		check.Error = fmt.Sprintf("the CRL %s is not signed by %s: %v", crl.Source, issuer.Subject.String(), err)
+		return
+	}
+
+	var errs []string
+	for _, entry := range crl.CRL.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+			continue
+		}
+
+		check.Revoked = true
+		check.RevokedAt = &entry.RevocationTime
+		check.Reason = crlReasonToString[entry.ReasonCode]
+		msg := fmt.Sprintf("the certificate %s is revoked at %s", check.Subject, entry.RevocationTime.UTC().Format(time.RFC3339))
+		if check.Reason != "" {
+			msg += ", reason " + check.Reason
+		}
+		errs = append(errs, msg)
+		break
+	}
+
+	if at.Before(crl.CRL.ThisUpdate) {
+		errs = append(errs, fmt.Sprintf("the CRL %s is not yet valid, this update is %s", crl.Source, crl.CRL.ThisUpdate.UTC().Format(time.RFC3339)))
+	}
+	if !crl.CRL.NextUpdate.IsZero() && at.After(crl.CRL.NextUpdate) {
+		errs = append(errs, fmt.Sprintf("the CRL %s is stale, next update was %s", crl.Source, crl.CRL.NextUpdate.UTC().Format(time.RFC3339)))
+	}
+
+	check.Error = strings.Join(errs, ", ")
+	check.Verified = len(errs) == 0
+}
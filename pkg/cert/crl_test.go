@@ -0,0 +1,131 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestCRL(t *testing.T, ca *x509.Certificate, caKey crypto.Signer, thisUpdate time.Time, revoked ...x509.RevocationListEntry) []byte {
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                thisUpdate,
+		NextUpdate:                thisUpdate.Add(24 * time.Hour),
+		RevokedCertificateEntries: revoked,
+	}, ca, caKey)
+	if err != nil {
+		t.Fatalf("failed CreateRevocationList: %v", err)
+	}
+
+	return der
+}
+
+func TestParseCRLs(t *testing.T) {
+	ca, caKey := newTestCert(t, nil, nil, testCertOptions{subject: "CN=Root CA", usage: "keyCertSign,cRLSign", isCA: true})
+	der := newTestCRL(t, ca, caKey, time.Now())
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: CRLBlockType, Bytes: der})
+
+	var tests = []struct {
+		data  []byte
+		count int
+		err   bool
+	}{
+		{data: der, count: 1},
+		{data: pemBytes, count: 1},
+		{data: append(append([]byte{}, pemBytes...), pemBytes...), count: 2},
+		{data: []byte("-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----\n"), err: true},
+		{data: []byte("junk"), err: true},
+	}
+
+	for i, test := range tests {
+		crls, err := ParseCRLs(test.data, "ca.crl")
+		if (err != nil) != test.err || len(crls) != test.count {
+			t.Errorf("failed ParseCRLs %d:\n\tactual: %d %v\n\texpect: %d\n", i, len(crls), err, test.count)
+		}
+	}
+}
+
+func TestCheckCRLs(t *testing.T) {
+	var crlBytes []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ca.crl" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(crlBytes)
+	}))
+	defer server.Close()
+
+	ca, caKey := newTestCert(t, nil, nil, testCertOptions{subject: "CN=Root CA", usage: "keyCertSign,cRLSign", isCA: true})
+	leaf, _ := newTestCert(t, ca, caKey, testCertOptions{subject: "CN=leaf.com", crlURL: server.URL + "/ca.crl"})
+	chain := []*x509.Certificate{leaf, ca}
+	now := time.Now()
+
+	revokedAt := now.Add(-10 * time.Minute).Truncate(time.Second)
+	revoked := x509.RevocationListEntry{SerialNumber: leaf.SerialNumber, RevocationTime: revokedAt, ReasonCode: 1}
+	goodCRL := newTestCRL(t, ca, caKey, now.Add(-time.Minute))
+	revokedCRL := newTestCRL(t, ca, caKey, now.Add(-time.Minute), revoked)
+
+	otherCA, otherKey := newTestCert(t, nil, nil, testCertOptions{subject: "CN=Other CA", usage: "keyCertSign,cRLSign", isCA: true})
+	otherCRL := newTestCRL(t, otherCA, otherKey, now.Add(-time.Minute))
+
+	var tests = []struct {
+		name    string
+		files   [][]byte
+		served  []byte
+		fetch   bool
+		at      time.Time
+		revoked bool
+		errMsg  string
+	}{
+		{name: "good file", files: [][]byte{goodCRL}},
+		{name: "revoked file", files: [][]byte{revokedCRL}, revoked: true, errMsg: "reason keyCompromise"},
+		{name: "latest file", files: [][]byte{revokedCRL, newTestCRL(t, ca, caKey, now.Add(-time.Hour))}, revoked: true, errMsg: "is revoked at"},
+		{name: "other issuer", files: [][]byte{otherCRL}, errMsg: "no CRL found for the issuer"},
+		{name: "stale", files: [][]byte{goodCRL}, at: now.Add(48 * time.Hour), errMsg: "is stale"},
+		{name: "not yet valid", files: [][]byte{goodCRL}, at: now.Add(-time.Hour), errMsg: "is not yet valid"},
+		{name: "fetch good", served: goodCRL, fetch: true},
+		{name: "fetch revoked", served: revokedCRL, fetch: true, revoked: true, errMsg: "reason keyCompromise"},
+		{name: "fetch failed", fetch: true, served: nil, errMsg: "Failed to parse CRL"},
+	}
+
+	for _, test := range tests {
+		var crls []*RevocationList
+		for _, data := range test.files {
+			list, err := ParseCRLs(data, "ca.crl")
+			if err != nil {
+				t.Fatalf("failed ParseCRLs: %v", err)
+			}
+			crls = append(crls, list...)
+		}
+		crlBytes = test.served
+
+		checks := CheckCRLs(chain, crls, test.fetch, test.at)
+		if len(checks) != 1 {
+			t.Errorf("failed CheckCRLs %s: expect 1 check, actual %d", test.name, len(checks))
+			continue
+		}
+		check := checks[0]
+		if check.Revoked != test.revoked || check.Verified != (test.errMsg == "") || !strings.Contains(check.Error, test.errMsg) {
+			t.Errorf("failed CheckCRLs %s:\n\tactual: %v %s\n\texpect: %v %s\n", test.name, check.Revoked, check.Error, test.revoked, test.errMsg)
+		}
+		if check.Revoked && !check.RevokedAt.Equal(revokedAt) {
+			t.Errorf("failed CheckCRLs %s revokedAt:\n\tactual: %v\n\texpect: %v\n", test.name, check.RevokedAt, revokedAt)
+		}
+	}
+
+	// the CRL signed by another CA with the same name
+	forgedCA, forgedKey := newTestCert(t, nil, nil, testCertOptions{subject: "CN=Root CA", usage: "keyCertSign,cRLSign", isCA: true})
+	forged, _ := ParseCRLs(newTestCRL(t, forgedCA, forgedKey, now.Add(-time.Minute)), "forged.crl")
+	checks := CheckCRLs(chain, forged, false, time.Time{})
+	if len(checks) != 1 || checks[0].Verified || !strings.Contains(checks[0].Error, "is not signed by") {
+		t.Errorf("failed CheckCRLs forged: %+v", checks)
+	}
+}
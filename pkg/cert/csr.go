@@ -0,0 +1,253 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// the policies of copying subject and subject alternative names from certificate request
+const (
+	// use the subject and subject alternative names from command line only
+	CopyExtensionsNone = "none"
+	// use the request subject if no subject provided and merge the subject alternative names
+	CopyExtensionsCopy = "copy"
+	// use the request subject and subject alternative names
+	CopyExtensionsCopyAll = "copyall"
+)
+
+var (
+	oidExtensionKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionExtendedKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37}
+	oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+)
+
+var ekuActionToOID = map[x509.ExtKeyUsage]asn1.ObjectIdentifier{
+	x509.ExtKeyUsageAny:                            {2, 5, 29, 37, 0},
+	x509.ExtKeyUsageServerAuth:                     {1, 3, 6, 1, 5, 5, 7, 3, 1},
+	x509.ExtKeyUsageClientAuth:                     {1, 3, 6, 1, 5, 5, 7, 3, 2},
+	x509.ExtKeyUsageCodeSigning:                    {1, 3, 6, 1, 5, 5, 7, 3, 3},
+	x509.ExtKeyUsageEmailProtection:                {1, 3, 6, 1, 5, 5, 7, 3, 4},
+	x509.ExtKeyUsageIPSECEndSystem:                 {1, 3, 6, 1, 5, 5, 7, 3, 5},
+	x509.ExtKeyUsageIPSECTunnel:                    {1, 3, 6, 1, 5, 5, 7, 3, 6},
+	x509.ExtKeyUsageIPSECUser:                      {1, 3, 6, 1, 5, 5, 7, 3, 7},
+	x509.ExtKeyUsageTimeStamping:                   {1, 3, 6, 1, 5, 5, 7, 3, 8},
+	x509.ExtKeyUsageOCSPSigning:                    {1, 3, 6, 1, 5, 5, 7, 3, 9},
+	x509.ExtKeyUsageMicrosoftServerGatedCrypto:     {1, 3, 6, 1, 4, 1, 311, 10, 3, 3},
+	x509.ExtKeyUsageNetscapeServerGatedCrypto:      {2, 16, 840, 1, 113730, 4, 1},
+	x509.ExtKeyUsageMicrosoftCommercialCodeSigning: {1, 3, 6, 1, 4, 1, 311, 2, 1, 22},
+	x509.ExtKeyUsageMicrosoftKernelCodeSigning:     {1, 3, 6, 1, 4, 1, 311, 61, 1, 1},
+}
+
+type basicConstraints struct {
+	IsCA       bool `asn1:"optional"`
+	MaxPathLen int  `asn1:"optional,default:-1"`
+}
+
+// NewCertRequestKey generates a private key and a PKCS#10 certificate request,
+// the key usages, extended key usages and basic constraints are added to the
+// request as requested extensions
+func NewCertRequestKey(certInfo *CertInfo, keyAlg *KeyAlgorithm) ([]byte, []byte, error) {
+	key, err := keyAlg.GenerateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	csrBytes, err := NewCertRequest(certInfo, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyBytes, err := EncodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return csrBytes, keyBytes, nil
+}
+
+// NewCertRequest generates a PKCS#10 certificate request with an existing private key
+func NewCertRequest(certInfo *CertInfo, key crypto.Signer) ([]byte, error) {
+	extensions, err := getRequestedExtensions(certInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	template := x509.CertificateRequest{
+		Subject:         *certInfo.Subject,
+		DNSNames:        certInfo.DNSNames,
+		IPAddresses:     certInfo.IPAddrs,
+		ExtraExtensions: extensions,
+	}
+
+	csrDERBytes, err := x509.CreateCertificateRequest(rand.Reader, &template, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: CertReqBlockType, Bytes: csrDERBytes}), nil
+}
+
+// ParseCertRequest parses the PEM or DER certificate request and verifies its self-signature
+func ParseCertRequest(csrBytes []byte) (*x509.CertificateRequest, error) {
+	pemBytes, err := ToPEM(csrBytes, "", CertReqBlockType)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse certificate request: %w", err)
+	}
+
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("Failed to parse certificate request")
+	}
+	if block.Type != CertReqBlockType {
+		return nil, fmt.Errorf("Not a Certificate Request")
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse certificate request: %w", err)
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("Failed to verify certificate request signature: %w", err)
+	}
+
+	return csr, nil
+}
+
+// NewCertInfoFromRequest is the same as NewCertInfo, but the subject and
+// subject alternative names are copied from certificate request per policy
+func NewCertInfoFromRequest(csr *x509.CertificateRequest, policy string, duration time.Duration, sub, san, usage, extUsage string, isCA bool) (*CertInfo, error) {
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	switch policy {
+	case CopyExtensionsNone:
+		return NewCertInfo(duration, sub, san, usage, extUsage, isCA)
+	case CopyExtensionsCopy, CopyExtensionsCopyAll:
+	default:
+		return nil, fmt.Errorf("Invalid copy extensions policy: %s", policy)
+	}
+
+	certInfo, err := newCertInfo(duration, san, usage, extUsage, isCA)
+	if err != nil {
+		return nil, err
+	}
+
+	if sub == "" || policy == CopyExtensionsCopyAll {
+		if csr.Subject.CommonName == "" {
+			return nil, fmt.Errorf("No Common Name specified in certificate request subject")
+		}
+		// keep all the attributes and their order
+		subject := csr.Subject
+		subject.ExtraNames = csr.Subject.Names
+		certInfo.Subject = &subject
+	} else {
+		subject, err := getSubject(sub)
+		if err != nil {
+			return nil, err
+		}
+		certInfo.Subject = subject
+	}
+
+	if policy == CopyExtensionsCopyAll {
+		certInfo.DNSNames = nil
+		certInfo.IPAddrs = nil
+	}
+
+	for _, name := range csr.DNSNames {
+		if !containString(certInfo.DNSNames, name) {
+			certInfo.DNSNames = append(certInfo.DNSNames, name)
+		}
+	}
+
+	for _, ip := range csr.IPAddresses {
+		if !containsIP(certInfo.IPAddrs, ip) {
+			certInfo.IPAddrs = append(certInfo.IPAddrs, ip)
+		}
+	}
+
+	return certInfo, nil
+}
+
+func getRequestedExtensions(certInfo *CertInfo) ([]pkix.Extension, error) {
+	var extensions []pkix.Extension
+
+	if certInfo.KeyUsage != 0 {
+		value, err := marshalKeyUsage(certInfo.KeyUsage)
+		if err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, pkix.Extension{Id: oidExtensionKeyUsage, Critical: true, Value: value})
+	}
+
+	if len(certInfo.ExtKeyUsage) > 0 {
+		var oids []asn1.ObjectIdentifier
+		for _, eku := range certInfo.ExtKeyUsage {
+			oid, ok := ekuActionToOID[eku]
+			if !ok {
+				return nil, fmt.Errorf("Unknown ExtKeyUsage: %d", eku)
+			}
+			oids = append(oids, oid)
+		}
+
+		value, err := asn1.Marshal(oids)
+		if err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, pkix.Extension{Id: oidExtensionExtendedKeyUsage, Value: value})
+	}
+
+	if certInfo.IsCA {
+		value, err := asn1.Marshal(basicConstraints{IsCA: true, MaxPathLen: -1})
+		if err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, pkix.Extension{Id: oidExtensionBasicConstraints, Critical: true, Value: value})
+	}
+
+	return extensions, nil
+}
+
+// the key usage bits are numbered from the most significant bit, see RFC 5280 section 4.2.1.3
+func marshalKeyUsage(ku x509.KeyUsage) ([]byte, error) {
+	var a [2]byte
+	a[0] = reverseBitsInAByte(byte(ku))
+	a[1] = reverseBitsInAByte(byte(ku >> 8))
+
+	l := 1
+	if a[1] != 0 {
+		l = 2
+	}
+
+	bitString := a[:l]
+	return asn1.Marshal(asn1.BitString{Bytes: bitString, BitLength: asn1BitLength(bitString)})
+}
+
+func reverseBitsInAByte(in byte) byte {
+	b1 := in>>4 | in<<4
+	b2 := b1>>2&0x33 | b1<<2&0xcc
+	b3 := b2>>1&0x55 | b2<<1&0xaa
+	return b3
+}
+
+func asn1BitLength(bitString []byte) int {
+	bitLen := len(bitString) * 8
+
+	for i := range bitString {
+		b := bitString[len(bitString)-i-1]
+
+		for bit := uint(0); bit < 8; bit++ {
+			if (b>>bit)&1 == 1 {
+				return bitLen
+			}
+			bitLen--
+		}
+	}
+
+	return 0
+}
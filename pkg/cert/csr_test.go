@@ -0,0 +1,146 @@
+package cert
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"slices"
+	"testing"
+)
+
+func TestNewCertRequestKey(t *testing.T) {
+	certInfo, err := NewCertInfo(0, "CN=china/O=China Inc", "china.com,127.0.0.1", "digitalSignature,keyEncipherment", "serverAuth,clientAuth", true)
+	if err != nil {
+		t.Fatalf("failed NewCertInfo: %v", err)
+	}
+
+	keyAlg, err := NewKeyAlgorithm(KeyTypeECDSA, 0, "P-256")
+	if err != nil {
+		t.Fatalf("failed NewKeyAlgorithm: %v", err)
+	}
+
+	csrBytes, _, err := NewCertRequestKey(certInfo, keyAlg)
+	if err != nil {
+		t.Fatalf("failed NewCertRequestKey: %v", err)
+	}
+
+	block, _ := pem.Decode(csrBytes)
+	if block == nil || block.Type != CertReqBlockType {
+		t.Fatalf("failed to decode certificate request")
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse certificate request: %v", err)
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		t.Errorf("failed to check signature: %v", err)
+	}
+
+	if csr.Subject.CommonName != "china" {
+		t.Errorf("failed Subject:\n\tactual: %v\n\texpect: %v\n", csr.Subject.CommonName, "china")
+	}
+
+	if !slices.Equal(csr.DNSNames, certInfo.DNSNames) {
+		t.Errorf("failed DNSNames:\n\tactual: %v\n\texpect: %v\n", csr.DNSNames, certInfo.DNSNames)
+	}
+
+	// subject alternative name, key usage, extended key usage and basic constraints
+	if len(csr.Extensions) != 4 {
+		t.Errorf("failed Extensions:\n\tactual: %d\n\texpect: %d\n", len(csr.Extensions), 4)
+	}
+
+	for _, ext := range csr.Extensions {
+		switch {
+		case ext.Id.Equal(oidExtensionKeyUsage):
+			if !ext.Critical {
+				t.Errorf("failed KeyUsage: not critical")
+			}
+		case ext.Id.Equal(oidExtensionBasicConstraints):
+			if !ext.Critical {
+				t.Errorf("failed BasicConstraints: not critical")
+			}
+		}
+	}
+}
+
+func TestMarshalKeyUsage(t *testing.T) {
+	var tests = []struct {
+		usage  x509.KeyUsage
+		expect []byte
+	}{
+		{usage: x509.KeyUsageDigitalSignature, expect: []byte{0x03, 0x02, 0x07, 0x80}},
+		{usage: x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment, expect: []byte{0x03, 0x02, 0x05, 0xa0}},
+		{usage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign, expect: []byte{0x03, 0x02, 0x01, 0x06}},
+		{usage: x509.KeyUsageDecipherOnly, expect: []byte{0x03, 0x03, 0x07, 0x00, 0x80}},
+	}
+
+	for _, test := range tests {
+		actual, err := marshalKeyUsage(test.usage)
+		if err != nil {
+			t.Fatalf("failed marshalKeyUsage: %v", err)
+		}
+		if !slices.Equal(actual, test.expect) {
+			t.Errorf("failed marshalKeyUsage:\n\tactual: %x\n\texpect: %x\n", actual, test.expect)
+		}
+	}
+}
+
+func TestNewCertInfoFromRequest(t *testing.T) {
+	certInfo, err := NewCertInfo(0, "CN=request/O=Request Inc", "request.com,10.0.0.1", "", "", false)
+	if err != nil {
+		t.Fatalf("failed NewCertInfo: %v", err)
+	}
+
+	keyAlg, err := NewKeyAlgorithm(KeyTypeEd25519, 0, "")
+	if err != nil {
+		t.Fatalf("failed NewKeyAlgorithm: %v", err)
+	}
+
+	csrBytes, _, err := NewCertRequestKey(certInfo, keyAlg)
+	if err != nil {
+		t.Fatalf("failed NewCertRequestKey: %v", err)
+	}
+
+	csr, err := ParseCertRequest(csrBytes)
+	if err != nil {
+		t.Fatalf("failed ParseCertRequest: %v", err)
+	}
+
+	var tests = []struct {
+		policy   string
+		subject  string
+		san      string
+		invalid  bool
+		expectCN string
+		expectNS []string
+	}{
+		{policy: CopyExtensionsNone, subject: "CN=flag", san: "flag.com", expectCN: "flag", expectNS: []string{"flag.com"}},
+		{policy: CopyExtensionsNone, subject: "", invalid: true},
+		{policy: CopyExtensionsCopy, subject: "", san: "flag.com", expectCN: "request", expectNS: []string{"flag.com", "request.com"}},
+		{policy: CopyExtensionsCopy, subject: "CN=flag", san: "request.com", expectCN: "flag", expectNS: []string{"request.com"}},
+		{policy: CopyExtensionsCopyAll, subject: "CN=flag", san: "flag.com", expectCN: "request", expectNS: []string{"request.com"}},
+		{policy: "unknown", subject: "CN=flag", invalid: true},
+	}
+
+	for _, test := range tests {
+		certInfo, err := NewCertInfoFromRequest(csr, test.policy, 0, test.subject, test.san, "", "", false)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("expect error for policy %s and subject %q", test.policy, test.subject)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed NewCertInfoFromRequest: %v", err)
+		}
+
+		if certInfo.Subject.CommonName != test.expectCN {
+			t.Errorf("failed NewCertInfoFromRequest.Subject:\n\tactual: %v\n\texpect: %v\n", certInfo.Subject.CommonName, test.expectCN)
+		}
+
+		if !slices.Equal(certInfo.DNSNames, test.expectNS) {
+			t.Errorf("failed NewCertInfoFromRequest.DNSNames:\n\tactual: %v\n\texpect: %v\n", certInfo.DNSNames, test.expectNS)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package cert
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"sort"
+)
+
+var (
+	oidAttributeChallengePassword  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 7}
+	oidAttributeExtensionRequest   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 14}
+	oidAttributeMSExtensionRequest = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 1, 14}
+)
+
+var attributeIDToName = map[string]string{
+	"1.2.840.113549.1.9.2":   "Unstructured Name",
+	"1.2.840.113549.1.9.8":   "Unstructured Address",
+	"1.2.840.113549.1.9.15":  "S/MIME Capabilities",
+	"1.2.840.113549.1.9.20":  "Friendly Name",
+	"1.3.6.1.4.1.311.13.2.2": "Microsoft Enrollment CSP",
+	"1.3.6.1.4.1.311.13.2.3": "Microsoft OS Version",
+	"1.3.6.1.4.1.311.21.20":  "Microsoft Request Client Info",
+}
+
+// RFC 2986 section 4.1, only the attributes are used
+type tbsCertificateRequest struct {
+	Raw           asn1.RawContent
+	Version       int
+	Subject       asn1.RawValue
+	PublicKey     asn1.RawValue
+	RawAttributes []asn1.RawValue `asn1:"tag:0"`
+}
+
+type csrAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// NewCertificateRequestInfo returns the machine-readable info of certificate
+// request, including the requested extensions, attributes and the result of
+// signature check
+func NewCertificateRequestInfo(csr *x509.CertificateRequest) *CertificateRequestInfo {
+	info := &CertificateRequestInfo{
+		Subject:            newName(csr.Subject),
+		SANs:               newSubjectAltNames(csr.DNSNames, csr.IPAddresses, csr.EmailAddresses, csr.URIs),
+		PublicKeyAlgorithm: csr.PublicKeyAlgorithm.String(),
+		SignatureAlgorithm: csr.SignatureAlgorithm.String(),
+		Signature:          NewVerifyCheck(csr.CheckSignature()),
+	}
+
+	if pub, err := NewPublicKeyInfo(csr.PublicKey); err == nil {
+		info.PublicKey = pub
+	}
+
+	for _, e := range csr.Extensions {
+		info.Extensions = append(info.Extensions, Extension{
+			ID:       e.Id.String(),
+			Name:     extensionName(e.Id.String()),
+			Critical: e.Critical,
+		})
+
+		switch {
+		case e.Id.Equal(oidExtensionKeyUsage):
+			info.KeyUsages = parseKeyUsages(e.Value)
+		case e.Id.Equal(oidExtensionExtendedKeyUsage):
+			info.ExtKeyUsages = parseExtKeyUsages(e.Value)
+		case e.Id.Equal(oidExtensionBasicConstraints):
+			bc := basicConstraints{MaxPathLen: -1}
+			if _, err := asn1.Unmarshal(e.Value, &bc); err == nil {
+				info.BasicConstraints = &BasicConstraints{IsCA: bc.IsCA, MaxPathLen: bc.MaxPathLen}
+			}
+		}
+	}
+
+	var tbs tbsCertificateRequest
+	if _, err := asn1.Unmarshal(csr.RawTBSCertificateRequest, &tbs); err != nil {
+		return info
+	}
+	for _, raw := range tbs.RawAttributes {
+		var attr csrAttribute
+		if _, err := asn1.Unmarshal(raw.FullBytes, &attr); err != nil {
+			continue
+		}
+
+		switch {
+		case attr.Type.Equal(oidAttributeExtensionRequest), attr.Type.Equal(oidAttributeMSExtensionRequest):
+			// parsed as extensions
+			continue
+		case attr.Type.Equal(oidAttributeChallengePassword):
+			if len(attr.Values) > 0 {
+				info.ChallengePassword = formatAttributeValue(attr.Values[0])
+			}
+			continue
+		}
+
+		attribute := Attribute{ID: attr.Type.String(), Name: attributeIDToName[attr.Type.String()]}
+		for _, v := range attr.Values {
+			attribute.Values = append(attribute.Values, formatAttributeValue(v))
+		}
+		info.Attributes = append(info.Attributes, attribute)
+	}
+
+	return info
+}
+
+// parseKeyUsages returns the names of key usage extension value
+func parseKeyUsages(value []byte) []string {
+	var bits asn1.BitString
+	if _, err := asn1.Unmarshal(value, &bits); err != nil {
+		return nil
+	}
+
+	var ku x509.KeyUsage
+	for i := 0; i < 9; i++ {
+		if bits.At(i) != 0 {
+			ku |= 1 << uint(i)
+		}
+	}
+
+	return keyUsageNames(ku)
+}
+
+// parseExtKeyUsages returns the names of extended key usage extension value,
+// the unknown usages are returned as OID
+func parseExtKeyUsages(value []byte) []string {
+	var oids []asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(value, &oids); err != nil {
+		return nil
+	}
+
+	var usages []string
+	for _, oid := range oids {
+		name := oid.String()
+		for eku, ekuOID := range ekuActionToOID {
+			if oid.Equal(ekuOID) {
+				name = ekuActionToString[eku]
+				break
+			}
+		}
+		usages = append(usages, name)
+	}
+	sort.Strings(usages)
+
+	return usages
+}
+
+// formatAttributeValue returns the string value of attribute, the non-string
+// value is returned as hex
+func formatAttributeValue(v asn1.RawValue) string {
+	var s string
+	if _, err := asn1.Unmarshal(v.FullBytes, &s); err == nil {
+		return s
+	}
+
+	return hex.EncodeToString(v.FullBytes)
+}
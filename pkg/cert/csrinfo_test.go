@@ -0,0 +1,92 @@
+package cert
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"slices"
+	"testing"
+)
+
+// generated by openssl req with challenge password, unstructured name,
+// email and URI SANs, and an unknown extended key usage
+const testFullCSR = `-----BEGIN CERTIFICATE REQUEST-----
+MIIBqTCCAU8CAQAwIjERMA8GA1UEAwwIY3NyLnRlc3QxDTALBgNVBAoMBFRlc3Qw
+WTATBgcqhkjOPQIBBggqhkjOPQMBBwNCAARo+S+9o43NAVbXVZmMZVHuePzvhh61
+zFlhMg968JEO+YKspQWU/jIcklta3Q/yjHpvjs6C5gntDZIP+7DfQ46qoIHKMBUG
+CSqGSIb3DQEJBzEIDAZzM2NyZXQwGAYJKoZIhvcNAQkCMQsMCUFjbWUgVW5pdDCB
+lgYJKoZIhvcNAQkOMYGIMIGFMC0GA1UdEQQmMCSCBWEuY29thwQBAgMEgQdhQGEu
+Y29thgxzcGlmZmU6Ly9hL2IwDgYDVR0PAQH/BAQDAgWgMCMGA1UdJQQcMBoGCCsG
+AQUFBwMBBggrBgEFBQcDAgYEKgMEBTASBgNVHRMBAf8ECDAGAQH/AgECMAsGAyoD
+CQQEDAJoaTAKBggqhkjOPQQDAgNIADBFAiB/0lQjP/4Rg8JN9X41V/g+vgO3jqUH
+lsKIn/RCxxMYFAIhALCIUKe8ELG2EvErmbXSt1CmvGmPfS6MK6hqZP9bi9Rb
+-----END CERTIFICATE REQUEST-----
+`
+
+func TestNewCertificateRequestInfo(t *testing.T) {
+	block, _ := pem.Decode([]byte(testFullCSR))
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed ParseCertificateRequest: %v", err)
+	}
+
+	info := NewCertificateRequestInfo(csr)
+
+	if !info.Signature.Verified {
+		t.Errorf("failed NewCertificateRequestInfo.Signature: %s", info.Signature.Error)
+	}
+	if info.PublicKey == nil || info.PublicKey.Algorithm != "ECDSA" || info.PublicKey.Curve != "P-256" {
+		t.Errorf("failed NewCertificateRequestInfo.PublicKey: %+v", info.PublicKey)
+	}
+	if !slices.Equal(info.SANs.EmailAddresses, []string{"a@a.com"}) || !slices.Equal(info.SANs.URIs, []string{"spiffe://a/b"}) {
+		t.Errorf("failed NewCertificateRequestInfo.SANs: %+v", info.SANs)
+	}
+	if expect := []string{"Digital Signature", "Key Encipherment"}; !slices.Equal(info.KeyUsages, expect) {
+		t.Errorf("failed NewCertificateRequestInfo.KeyUsages:\n\tactual: %v\n\texpect: %v\n", info.KeyUsages, expect)
+	}
+	if expect := []string{"1.2.3.4.5", "TLS Web Client Authentication", "TLS Web Server Authentication"}; !slices.Equal(info.ExtKeyUsages, expect) {
+		t.Errorf("failed NewCertificateRequestInfo.ExtKeyUsages:\n\tactual: %v\n\texpect: %v\n", info.ExtKeyUsages, expect)
+	}
+	if bc := info.BasicConstraints; bc == nil || !bc.IsCA || bc.MaxPathLen != 2 {
+		t.Errorf("failed NewCertificateRequestInfo.BasicConstraints: %+v", bc)
+	}
+	if len(info.Extensions) != 5 || info.Extensions[4].ID != "1.2.3.9" {
+		t.Errorf("failed NewCertificateRequestInfo.Extensions: %+v", info.Extensions)
+	}
+	if info.ChallengePassword != "s3cret" {
+		t.Errorf("failed NewCertificateRequestInfo.ChallengePassword:\n\tactual: %s\n\texpect: %s\n", info.ChallengePassword, "s3cret")
+	}
+	expect := []Attribute{{ID: "1.2.840.113549.1.9.2", Name: "Unstructured Name", Values: []string{"Acme Unit"}}}
+	if len(info.Attributes) != 1 || info.Attributes[0].ID != expect[0].ID || info.Attributes[0].Name != expect[0].Name || !slices.Equal(info.Attributes[0].Values, expect[0].Values) {
+		t.Errorf("failed NewCertificateRequestInfo.Attributes:\n\tactual: %+v\n\texpect: %+v\n", info.Attributes, expect)
+	}
+}
+
+func TestNewCertificateRequestInfoBadSignature(t *testing.T) {
+	certInfo, err := NewCertInfo(0, "CN=china", "china.com", "digitalSignature", "serverAuth", false)
+	if err != nil {
+		t.Fatalf("failed NewCertInfo: %v", err)
+	}
+	keyAlg, _ := NewKeyAlgorithm(KeyTypeRSA, 2048, "")
+	csrBytes, _, err := NewCertRequestKey(certInfo, keyAlg)
+	if err != nil {
+		t.Fatalf("failed NewCertRequestKey: %v", err)
+	}
+
+	block, _ := pem.Decode(csrBytes)
+	block.Bytes[len(block.Bytes)-1] ^= 0xff
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed ParseCertificateRequest: %v", err)
+	}
+
+	info := NewCertificateRequestInfo(csr)
+	if info.Signature.Verified || info.Signature.Error == "" {
+		t.Errorf("failed NewCertificateRequestInfo.Signature: expect failure, actual %+v", info.Signature)
+	}
+	if info.PublicKey == nil || info.PublicKey.Size != 2048 {
+		t.Errorf("failed NewCertificateRequestInfo.PublicKey: %+v", info.PublicKey)
+	}
+	if info.BasicConstraints != nil || info.ChallengePassword != "" || len(info.Attributes) != 0 {
+		t.Errorf("failed NewCertificateRequestInfo: unexpected %+v", info)
+	}
+}
@@ -4,8 +4,17 @@ import (
 	"bytes"
 	"crypto/tls"
 	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
 )
 
+// the max size of response body, CRLs of public CAs are tens of megabytes
+const maxFetchSize = 64 << 20
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
 func FetchCert(addr string) ([]byte, error) {
 	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
 	if err != nil {
@@ -27,3 +36,33 @@ func FetchCert(addr string) ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+// fetchURL returns the response body of HTTP GET url
+func fetchURL(url string) ([]byte, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to fetch %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxFetchSize))
+}
+
+// postURL returns the response body of HTTP POST url
+func postURL(url, contentType string, body []byte) ([]byte, error) {
+	resp, err := httpClient.Post(url, contentType, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to post %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxFetchSize))
+}
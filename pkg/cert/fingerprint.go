@@ -0,0 +1,45 @@
+package cert
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+)
+
+// GetFingerprints returns the SHA-256 and SHA-1 fingerprints and the SPKI pin
+// of certificate
+func GetFingerprints(cert *x509.Certificate) Fingerprints {
+	sha256Sum := sha256.Sum256(cert.Raw)
+	sha1Sum := sha1.Sum(cert.Raw)
+
+	return Fingerprints{
+		SHA256:     formatHex(sha256Sum[:]),
+		SHA1:       formatHex(sha1Sum[:]),
+		SPKISHA256: GetSPKIPin(cert.RawSubjectPublicKeyInfo),
+	}
+}
+
+// GetSPKIPin returns the base64 encoded SHA-256 digest of DER subject public
+// key info, which is the pin-sha256 value of HTTP Public Key Pinning
+func GetSPKIPin(spki []byte) string {
+	sum := sha256.Sum256(spki)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// GetCertFingerprints returns one line of fingerprints per certificate
+func GetCertFingerprints(certBytes []byte) ([]string, error) {
+	certs, err := ParseCerts(certBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, cert := range certs {
+		fp := GetFingerprints(cert)
+		lines = append(lines, fmt.Sprintf("SHA256=%s SHA1=%s SPKI-SHA256=%s %s", fp.SHA256, fp.SHA1, fp.SPKISHA256, cert.Subject))
+	}
+
+	return lines, nil
+}
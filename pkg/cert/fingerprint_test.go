@@ -0,0 +1,44 @@
+package cert
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+)
+
+func TestGetSPKIPin(t *testing.T) {
+	// the SHA-256 digest of empty input
+	expect := "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	if actual := GetSPKIPin(nil); actual != expect {
+		t.Errorf("failed GetSPKIPin:\n\tactual: %s\n\texpect: %s\n", actual, expect)
+	}
+}
+
+func TestGetCertFingerprints(t *testing.T) {
+	var bundle []byte
+	for _, subject := range []string{"CN=one", "CN=two"} {
+		cert, _ := newTestCert(t, nil, nil, testCertOptions{subject: subject})
+		bundle = append(bundle, EncodeCerts([]*x509.Certificate{cert})...)
+	}
+
+	lines, err := GetCertFingerprints(bundle)
+	if err != nil {
+		t.Fatalf("failed GetCertFingerprints: %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("failed GetCertFingerprints:\n\tactual: %d lines\n\texpect: 2 lines\n", len(lines))
+	}
+
+	certs, _ := ParseCerts(bundle)
+	for i, line := range lines {
+		fp := GetFingerprints(certs[i])
+		if fp.SPKISHA256 != GetSPKIPin(certs[i].RawSubjectPublicKeyInfo) {
+			t.Errorf("failed GetFingerprints.SPKISHA256: %s", fp.SPKISHA256)
+		}
+		for _, expect := range []string{"SHA256=" + fp.SHA256, "SHA1=" + fp.SHA1, "SPKI-SHA256=" + fp.SPKISHA256, certs[i].Subject.String()} {
+			if !strings.Contains(line, expect) {
+				t.Errorf("failed GetCertFingerprints:\n\tactual: %s\n\texpect: %s\n", line, expect)
+			}
+		}
+	}
+}
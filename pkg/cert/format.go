@@ -0,0 +1,188 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+const (
+	FormatPEM   = "pem"
+	FormatDER   = "der"
+	FormatPKCS7 = "pkcs7"
+)
+
+// GetFormat validates the format name, p7b and p7c are aliases of pkcs7,
+// the empty format means detecting automatically
+func GetFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "":
+		return "", nil
+	case FormatPEM:
+		return FormatPEM, nil
+	case FormatDER:
+		return FormatDER, nil
+	case FormatPKCS7, "p7b", "p7c":
+		return FormatPKCS7, nil
+	}
+
+	return "", fmt.Errorf("Invalid format: %s, must be pem, der or pkcs7", format)
+}
+
+// DetectFormat returns the format of data: pem, der or pkcs7. The PEM encoded
+// PKCS#7 is treated as pkcs7.
+func DetectFormat(data []byte) string {
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type == PKCS7BlockType {
+			return FormatPKCS7
+		}
+		return FormatPEM
+	}
+
+	if IsPKCS7(data) {
+		return FormatPKCS7
+	}
+
+	return FormatDER
+}
+
+// ToPEM converts the DER or PKCS#7 data to PEM, the blockType is the PEM
+// block type of DER data. The format is detected if it is empty.
+func ToPEM(data []byte, format, blockType string) ([]byte, error) {
+	format, err := GetFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = DetectFormat(data)
+	}
+
+	switch format {
+	case FormatPEM:
+		if block, _ := pem.Decode(data); block == nil {
+			return nil, fmt.Errorf("Failed to decode PEM data")
+		}
+		return data, nil
+	case FormatDER:
+		if blockType == PrivateKeyBlockType {
+			blockType = getKeyBlockType(data)
+		} else if blockType == PublicKeyBlockType {
+			blockType = getPublicKeyBlockType(data)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), nil
+	case FormatPKCS7:
+		// PEM encoded PKCS#7
+		if block, _ := pem.Decode(data); block != nil && block.Type == PKCS7BlockType {
+			data = block.Bytes
+		}
+		certs, err := DecodePKCS7Certs(data)
+		if err != nil {
+			return nil, err
+		}
+		return EncodeCerts(certs), nil
+	}
+
+	return nil, fmt.Errorf("Invalid format: %s", format)
+}
+
+// FromPEM converts the PEM data to format, the DER format can only contain
+// one PEM block and the PKCS#7 format can only contain certificates
+func FromPEM(pemBytes []byte, format string) ([]byte, error) {
+	format, err := GetFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []*pem.Block
+	rest := pemBytes
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("Failed to decode PEM data")
+	}
+
+	switch format {
+	case "", FormatPEM:
+		return pemBytes, nil
+	case FormatDER:
+		if len(blocks) > 1 {
+			return nil, fmt.Errorf("DER format can only contain one certificate or key, use pkcs7 for multiple certificates")
+		}
+		if x509.IsEncryptedPEMBlock(blocks[0]) {
+			return nil, fmt.Errorf("DER format does not support legacy encrypted PEM private key")
+		}
+		return blocks[0].Bytes, nil
+	case FormatPKCS7:
+		var buf bytes.Buffer
+		for _, block := range blocks {
+			if block.Type != CertBlockType {
+				return nil, fmt.Errorf("PKCS#7 format can only contain certificates, found %s", block.Type)
+			}
+			buf.Write(block.Bytes)
+		}
+		certs, err := x509.ParseCertificates(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse certificate: %w", err)
+		}
+		return EncodePKCS7Certs(certs)
+	}
+
+	return nil, fmt.Errorf("Invalid format: %s", format)
+}
+
+// DetectDERBlockType returns the PEM block type of DER data, which can be
+// certificate, certificate request, public key or private key
+func DetectDERBlockType(der []byte) string {
+	if _, err := x509.ParseCertificate(der); err == nil {
+		return CertBlockType
+	}
+	if _, err := x509.ParseCertificateRequest(der); err == nil {
+		return CertReqBlockType
+	}
+	if _, err := x509.ParsePKIXPublicKey(der); err == nil {
+		return PublicKeyBlockType
+	}
+	if _, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return RSAPublicKeyBlockType
+	}
+
+	return getKeyBlockType(der)
+}
+
+// getKeyBlockType returns the PEM block type of DER private key
+func getKeyBlockType(der []byte) string {
+	if _, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return RSAKeyBlockType
+	}
+	if _, err := x509.ParseECPrivateKey(der); err == nil {
+		return ECKEYBlockType
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return PrivateKeyBlockType
+	}
+
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err == nil && len(rest) == 0 {
+		return EncryptedPrivateKeyBlockType
+	}
+
+	return PrivateKeyBlockType
+}
+
+// getPublicKeyBlockType returns the PEM block type of DER public key
+func getPublicKeyBlockType(der []byte) string {
+	if _, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return RSAPublicKeyBlockType
+	}
+
+	return PublicKeyBlockType
+}
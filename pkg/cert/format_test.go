@@ -0,0 +1,136 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+)
+
+func TestConvertFormat(t *testing.T) {
+	keyAlg, _ := NewKeyAlgorithm(KeyTypeECDSA, 0, "P-256")
+	key, err := keyAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+
+	var certs []*x509.Certificate
+	for _, subject := range []string{"CN=china", "CN=root-ca"} {
+		certInfo, err := NewCertInfo(time.Hour, subject, "", "", "", false)
+		if err != nil {
+			t.Fatalf("failed NewCertInfo: %v", err)
+		}
+		certBytes, err := NewCert(certInfo, key)
+		if err != nil {
+			t.Fatalf("failed NewCert: %v", err)
+		}
+		cert, _ := ParseCert(certBytes)
+		certs = append(certs, cert)
+	}
+
+	pemBytes := EncodeCerts(certs)
+
+	p7b, err := FromPEM(pemBytes, "p7b")
+	if err != nil {
+		t.Fatalf("failed FromPEM pkcs7: %v", err)
+	}
+
+	der, err := FromPEM(EncodeCerts(certs[:1]), FormatDER)
+	if err != nil {
+		t.Fatalf("failed FromPEM der: %v", err)
+	}
+
+	if _, err := FromPEM(pemBytes, FormatDER); err == nil {
+		t.Errorf("failed FromPEM der: expect error for multiple certificates")
+	}
+
+	var tests = []struct {
+		data   []byte
+		format string
+		count  int
+	}{
+		{data: pemBytes, format: FormatPEM, count: 2},
+		{data: der, format: FormatDER, count: 1},
+		{data: p7b, format: FormatPKCS7, count: 2},
+		{data: pem.EncodeToMemory(&pem.Block{Type: PKCS7BlockType, Bytes: p7b}), format: FormatPKCS7, count: 2},
+	}
+
+	for _, test := range tests {
+		if format := DetectFormat(test.data); format != test.format {
+			t.Errorf("failed DetectFormat:\n\tactual: %s\n\texpect: %s\n", format, test.format)
+		}
+
+		parsed, err := ParseCerts(test.data)
+		if err != nil {
+			t.Fatalf("failed ParseCerts with %s: %v", test.format, err)
+		}
+		if len(parsed) != test.count {
+			t.Fatalf("failed ParseCerts with %s:\n\tactual: %d\n\texpect: %d\n", test.format, len(parsed), test.count)
+		}
+		for i := range parsed {
+			if !parsed[i].Equal(certs[i]) {
+				t.Errorf("failed ParseCerts with %s: certificate %d mismatch", test.format, i)
+			}
+		}
+	}
+}
+
+func TestParseDERKey(t *testing.T) {
+	var tests = []struct {
+		keyType    string
+		passphrase []byte
+		blockType  string
+	}{
+		{keyType: KeyTypeRSA, blockType: RSAKeyBlockType},
+		{keyType: KeyTypeECDSA, blockType: ECKEYBlockType},
+		{keyType: KeyTypeEd25519, blockType: PrivateKeyBlockType},
+		{keyType: KeyTypeECDSA, passphrase: []byte("secret"), blockType: EncryptedPrivateKeyBlockType},
+	}
+
+	for _, test := range tests {
+		keyAlg, _ := NewKeyAlgorithm(test.keyType, 2048, "P-256")
+		key, err := keyAlg.GenerateKey()
+		if err != nil {
+			t.Fatalf("failed GenerateKey: %v", err)
+		}
+
+		var keyBytes []byte
+		if len(test.passphrase) > 0 {
+			keyBytes, err = EncodeEncryptedKey(key, test.passphrase, KDFPBKDF2)
+		} else {
+			keyBytes, err = EncodeKey(key)
+		}
+		if err != nil {
+			t.Fatalf("failed to encode key: %v", err)
+		}
+
+		der, err := FromPEM(keyBytes, FormatDER)
+		if err != nil {
+			t.Fatalf("failed FromPEM: %v", err)
+		}
+
+		pemBytes, err := ToPEM(der, FormatDER, PrivateKeyBlockType)
+		if err != nil {
+			t.Fatalf("failed ToPEM: %v", err)
+		}
+		if !bytes.Equal(pemBytes, keyBytes) {
+			t.Errorf("failed ToPEM with %s: expect block type %s", test.keyType, test.blockType)
+		}
+
+		signer, err := ParseSigner(der, test.passphrase)
+		if err != nil {
+			t.Fatalf("failed ParseSigner with %s: %v", test.keyType, err)
+		}
+		if !PublicKeyEqual(signer.Public(), key.Public()) {
+			t.Errorf("failed ParseSigner with %s: public key mismatch", test.keyType)
+		}
+	}
+
+	if _, err := FromPEM([]byte("not pem"), FormatPEM); err == nil {
+		t.Errorf("failed FromPEM: expect error for invalid PEM")
+	}
+	if _, err := GetFormat("jks"); err == nil {
+		t.Errorf("failed GetFormat: expect error for invalid format")
+	}
+}
@@ -2,19 +2,34 @@ package cert
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/rand"
-	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"time"
 )
 
-func NewCertKey(certInfo *CertInfo, rsaKeySize int) ([]byte, []byte, error) {
-	key, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
+func NewCertKey(certInfo *CertInfo, keyAlg *KeyAlgorithm) ([]byte, []byte, error) {
+	key, err := keyAlg.GenerateKey()
 	if err != nil {
 		return nil, nil, err
 	}
 
+	certBytes, err := NewCert(certInfo, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyBytes, err := EncodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return certBytes, keyBytes, nil
+}
+
+// NewCert generates a self-signed certificate with an existing private key
+func NewCert(certInfo *CertInfo, key crypto.Signer) ([]byte, error) {
 	now := time.Now()
 	template := x509.Certificate{
 		SerialNumber:          certInfo.SerialNumber,
@@ -31,18 +46,13 @@ func NewCertKey(certInfo *CertInfo, rsaKeySize int) ([]byte, []byte, error) {
 
 	certDERBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, key.Public(), key)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	certBuffer := bytes.Buffer{}
 	if err := pem.Encode(&certBuffer, &pem.Block{Type: CertBlockType, Bytes: certDERBytes}); err != nil {
-		return nil, nil, err
-	}
-
-	keyBuffer := bytes.Buffer{}
-	if err := pem.Encode(&keyBuffer, &pem.Block{Type: RSAKeyBlockType, Bytes: x509.MarshalPKCS1PrivateKey(key)}); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	return certBuffer.Bytes(), keyBuffer.Bytes(), err
+	return certBuffer.Bytes(), nil
 }
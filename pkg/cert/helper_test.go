@@ -0,0 +1,85 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"testing"
+	"time"
+)
+
+// testCertOptions are the options of newTestCert, the subject, SANs and
+// usages are in the format of NewCertInfo
+type testCertOptions struct {
+	subject  string
+	san      string
+	usage    string
+	extUsage string
+	isCA     bool
+	// the private key of the certificate, a new ECDSA P-256 key if nil
+	key    crypto.Signer
+	crlURL string
+	aiaURL string
+}
+
+// newTestCert issues the certificate valid for an hour from the parent, or
+// self-signed if parent is nil, and returns it with its private key
+func newTestCert(t *testing.T, parent *x509.Certificate, parentKey crypto.Signer, opts testCertOptions) (*x509.Certificate, crypto.Signer) {
+	key := opts.key
+	if key == nil {
+		keyAlg, _ := NewKeyAlgorithm(KeyTypeECDSA, 0, "P-256")
+		var err error
+		key, err = keyAlg.GenerateKey()
+		if err != nil {
+			t.Fatalf("failed GenerateKey: %v", err)
+		}
+	}
+
+	certInfo, err := NewCertInfo(time.Hour, opts.subject, opts.san, opts.usage, opts.extUsage, opts.isCA)
+	if err != nil {
+		t.Fatalf("failed NewCertInfo: %v", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          certInfo.SerialNumber,
+		Subject:               *certInfo.Subject,
+		NotBefore:             now.UTC(),
+		NotAfter:              now.Add(certInfo.Duration).UTC(),
+		KeyUsage:              certInfo.KeyUsage,
+		ExtKeyUsage:           certInfo.ExtKeyUsage,
+		BasicConstraintsValid: true,
+		IsCA:                  certInfo.IsCA,
+		DNSNames:              certInfo.DNSNames,
+		IPAddresses:           certInfo.IPAddrs,
+	}
+	if opts.crlURL != "" {
+		template.CRLDistributionPoints = []string{opts.crlURL}
+	}
+	if opts.aiaURL != "" {
+		template.IssuingCertificateURL = []string{opts.aiaURL}
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatalf("failed CreateCertificate %s: %v", opts.subject, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed ParseCertificate: %v", err)
+	}
+
+	return cert, key
+}
+
+// newTestChain returns the root, intermediate and leaf certificates
+func newTestChain(t *testing.T) []*x509.Certificate {
+	root, rootKey := newTestCert(t, nil, nil, testCertOptions{subject: "CN=Root CA", isCA: true})
+	inter, interKey := newTestCert(t, root, rootKey, testCertOptions{subject: "CN=Inter CA", isCA: true})
+	leaf, _ := newTestCert(t, inter, interKey, testCertOptions{subject: "CN=leaf.com"})
+
+	return []*x509.Certificate{root, inter, leaf}
+}
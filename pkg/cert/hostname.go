@@ -0,0 +1,108 @@
+package cert
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// HostnameMatch is the result of matching hostname or IP address against
+// the subject alternative names of certificate
+type HostnameMatch struct {
+	Host    string     `json:"host" yaml:"host"`
+	Matched bool       `json:"matched" yaml:"matched"`
+	Checked []SANCheck `json:"checked" yaml:"checked"`
+	Error   string     `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// SANCheck is the result of matching one subject alternative name
+type SANCheck struct {
+	SAN     string `json:"san" yaml:"san"`
+	Matched bool   `json:"matched" yaml:"matched"`
+	Reason  string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// MatchHostname matches the hostname or IP address against the DNS or IP
+// SANs of certificate, the Common Name is not used as crypto/x509 does
+func MatchHostname(cert *x509.Certificate, host string) *HostnameMatch {
+	match := &HostnameMatch{Host: host}
+
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		for _, san := range cert.IPAddresses {
+			check := SANCheck{SAN: "IP:" + san.String(), Matched: san.Equal(ip)}
+			if !check.Matched {
+				check.Reason = "different IP address"
+			}
+			match.Checked = append(match.Checked, check)
+		}
+	} else {
+		name := strings.ToLower(strings.TrimSuffix(host, "."))
+		for _, san := range cert.DNSNames {
+			check := SANCheck{SAN: "DNS:" + san}
+			check.Matched, check.Reason = matchDNSName(strings.ToLower(strings.TrimSuffix(san, ".")), name)
+			match.Checked = append(match.Checked, check)
+		}
+	}
+
+	if err := cert.VerifyHostname(host); err != nil {
+		match.Error = formatHostnameError(cert, match)
+		return match
+	}
+	match.Matched = true
+
+	return match
+}
+
+// matchDNSName matches the name against the DNS SAN which may be wildcard,
+// returns the reason if not matched
+func matchDNSName(san, name string) (bool, string) {
+	if san == name {
+		return true, ""
+	}
+
+	if !strings.HasPrefix(san, "*.") {
+		return false, "different name"
+	}
+
+	suffix := san[1:]
+	if name == san[2:] {
+		return false, "wildcard does not match the parent domain"
+	}
+	if !strings.HasSuffix(name, suffix) {
+		return false, "different domain"
+	}
+
+	label := strings.TrimSuffix(name, suffix)
+	if strings.Contains(label, ".") {
+		return false, "wildcard matches only a single label"
+	}
+	if label == "" {
+		return false, "empty label"
+	}
+
+	return true, ""
+}
+
+func formatHostnameError(cert *x509.Certificate, match *HostnameMatch) string {
+	if len(match.Checked) == 0 {
+		if net.ParseIP(strings.Trim(match.Host, "[]")) != nil {
+			return fmt.Sprintf("the certificate does not match %s: no IP SANs", match.Host)
+		}
+		if cert.Subject.CommonName != "" {
+			return fmt.Sprintf("the certificate does not match %s: no DNS SANs, the Common Name %q is not used", match.Host, cert.Subject.CommonName)
+		}
+		return fmt.Sprintf("the certificate does not match %s: no DNS SANs", match.Host)
+	}
+
+	var checked []string
+	for _, c := range match.Checked {
+		if c.Reason == "" {
+			checked = append(checked, c.SAN)
+		} else {
+			checked = append(checked, fmt.Sprintf("%s (%s)", c.SAN, c.Reason))
+		}
+	}
+
+	return fmt.Sprintf("the certificate does not match %s, checked %s", match.Host, strings.Join(checked, ", "))
+}
@@ -0,0 +1,65 @@
+package cert
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchDNSName(t *testing.T) {
+	var tests = []struct {
+		san     string
+		name    string
+		matched bool
+		reason  string
+	}{
+		{san: "a.com", name: "a.com", matched: true},
+		{san: "a.com", name: "b.com", reason: "different name"},
+		{san: "*.a.com", name: "x.a.com", matched: true},
+		{san: "*.a.com", name: "x.y.a.com", reason: "wildcard matches only a single label"},
+		{san: "*.a.com", name: "a.com", reason: "wildcard does not match the parent domain"},
+		{san: "*.a.com", name: "x.b.com", reason: "different domain"},
+	}
+
+	for _, test := range tests {
+		matched, reason := matchDNSName(test.san, test.name)
+		if matched != test.matched || reason != test.reason {
+			t.Errorf("failed matchDNSName %s %s:\n\tactual: %v %q\n\texpect: %v %q\n", test.san, test.name, matched, reason, test.matched, test.reason)
+		}
+	}
+}
+
+func TestMatchHostname(t *testing.T) {
+	cert, key := newTestCert(t, nil, nil, testCertOptions{subject: "CN=china.com", san: "*.china.com,china.com,10.0.0.1,::1"})
+
+	var tests = []struct {
+		host    string
+		matched bool
+		checked int
+		errMsg  string
+	}{
+		{host: "www.china.com", matched: true, checked: 2},
+		{host: "WWW.China.com.", matched: true, checked: 2},
+		{host: "china.com", matched: true, checked: 2},
+		{host: "a.b.china.com", checked: 2, errMsg: "DNS:*.china.com (wildcard matches only a single label)"},
+		{host: "10.0.0.1", matched: true, checked: 2},
+		{host: "[::1]", matched: true, checked: 2},
+		{host: "10.0.0.2", checked: 2, errMsg: "IP:10.0.0.1 (different IP address)"},
+	}
+
+	for _, test := range tests {
+		match := MatchHostname(cert, test.host)
+		if match.Matched != test.matched || len(match.Checked) != test.checked {
+			t.Errorf("failed MatchHostname %s:\n\tactual: %v %d\n\texpect: %v %d\n", test.host, match.Matched, len(match.Checked), test.matched, test.checked)
+		}
+		if !strings.Contains(match.Error, test.errMsg) || (test.errMsg == "") != (match.Error == "") {
+			t.Errorf("failed MatchHostname %s error:\n\tactual: %s\n\texpect: %s\n", test.host, match.Error, test.errMsg)
+		}
+	}
+
+	// the Common Name is not used
+	cert, _ = newTestCert(t, nil, nil, testCertOptions{subject: "CN=china.com", key: key})
+	match := MatchHostname(cert, "china.com")
+	if match.Matched || !strings.Contains(match.Error, "no DNS SANs") {
+		t.Errorf("failed MatchHostname without SANs: %+v", match)
+	}
+}
@@ -0,0 +1,236 @@
+package cert
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// the extensions shown separately in text output
+var extraExtensionIDToName = map[string]string{
+	"2.5.29.15":               "Key Usage",
+	"2.5.29.17":               "Subject Alternative Name",
+	"2.5.29.31":               "CRL Distribution Points",
+	"2.5.29.37":               "Extended Key Usage",
+	"1.3.6.1.5.5.7.1.1":       "Authority Information Access",
+	"1.3.6.1.4.1.11129.2.4.2": "CT Precertificate SCTs",
+}
+
+// Name is the subject or issuer of certificate
+type Name struct {
+	DN                 string   `json:"dn" yaml:"dn"`
+	CommonName         string   `json:"commonName,omitempty" yaml:"commonName,omitempty"`
+	Country            []string `json:"country,omitempty" yaml:"country,omitempty"`
+	Province           []string `json:"province,omitempty" yaml:"province,omitempty"`
+	Locality           []string `json:"locality,omitempty" yaml:"locality,omitempty"`
+	Organization       []string `json:"organization,omitempty" yaml:"organization,omitempty"`
+	OrganizationalUnit []string `json:"organizationalUnit,omitempty" yaml:"organizationalUnit,omitempty"`
+}
+
+// SubjectAltNames are the subject alternative names of certificate
+type SubjectAltNames struct {
+	DNSNames       []string `json:"dnsNames,omitempty" yaml:"dnsNames,omitempty"`
+	IPAddresses    []string `json:"ipAddresses,omitempty" yaml:"ipAddresses,omitempty"`
+	EmailAddresses []string `json:"emailAddresses,omitempty" yaml:"emailAddresses,omitempty"`
+	URIs           []string `json:"uris,omitempty" yaml:"uris,omitempty"`
+}
+
+// Fingerprints are the hex encoded digests of DER certificate and the base64
+// encoded SHA-256 digest of subject public key info
+type Fingerprints struct {
+	SHA256     string `json:"sha256" yaml:"sha256"`
+	SHA1       string `json:"sha1" yaml:"sha1"`
+	SPKISHA256 string `json:"spkiSHA256" yaml:"spkiSHA256"`
+}
+
+// Extension is the certificate extension
+type Extension struct {
+	ID       string `json:"id" yaml:"id"`
+	Name     string `json:"name,omitempty" yaml:"name,omitempty"`
+	Critical bool   `json:"critical" yaml:"critical"`
+}
+
+// CertificateInfo is the machine-readable info of certificate
+type CertificateInfo struct {
+	Subject            Name            `json:"subject" yaml:"subject"`
+	Issuer             Name            `json:"issuer" yaml:"issuer"`
+	SANs               SubjectAltNames `json:"sans" yaml:"sans"`
+	SerialNumber       string          `json:"serialNumber" yaml:"serialNumber"`
+	IsCA               bool            `json:"isCA" yaml:"isCA"`
+	NotBefore          time.Time       `json:"notBefore" yaml:"notBefore"`
+	NotAfter           time.Time       `json:"notAfter" yaml:"notAfter"`
+	KeyUsages          []string        `json:"keyUsages,omitempty" yaml:"keyUsages,omitempty"`
+	ExtKeyUsages       []string        `json:"extKeyUsages,omitempty" yaml:"extKeyUsages,omitempty"`
+	PublicKeyAlgorithm string          `json:"publicKeyAlgorithm" yaml:"publicKeyAlgorithm"`
+	SignatureAlgorithm string          `json:"signatureAlgorithm" yaml:"signatureAlgorithm"`
+	Fingerprints       Fingerprints    `json:"fingerprints" yaml:"fingerprints"`
+	Extensions         []Extension     `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+}
+
+// CertificateRequestInfo is the machine-readable info of certificate request,
+// the key usages, extended key usages and basic constraints are requested by
+// the extension request attribute
+type CertificateRequestInfo struct {
+	Subject            Name              `json:"subject" yaml:"subject"`
+	SANs               SubjectAltNames   `json:"sans" yaml:"sans"`
+	PublicKeyAlgorithm string            `json:"publicKeyAlgorithm" yaml:"publicKeyAlgorithm"`
+	PublicKey          *KeyInfo          `json:"publicKey,omitempty" yaml:"publicKey,omitempty"`
+	SignatureAlgorithm string            `json:"signatureAlgorithm" yaml:"signatureAlgorithm"`
+	Signature          *VerifyCheck      `json:"signature" yaml:"signature"`
+	KeyUsages          []string          `json:"keyUsages,omitempty" yaml:"keyUsages,omitempty"`
+	ExtKeyUsages       []string          `json:"extKeyUsages,omitempty" yaml:"extKeyUsages,omitempty"`
+	BasicConstraints   *BasicConstraints `json:"basicConstraints,omitempty" yaml:"basicConstraints,omitempty"`
+	Extensions         []Extension       `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+	ChallengePassword  string            `json:"challengePassword,omitempty" yaml:"challengePassword,omitempty"`
+	Attributes         []Attribute       `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+}
+
+// BasicConstraints is the basic constraints extension, the MaxPathLen is -1
+// if not set
+type BasicConstraints struct {
+	IsCA       bool `json:"isCA" yaml:"isCA"`
+	MaxPathLen int  `json:"maxPathLen" yaml:"maxPathLen"`
+}
+
+// Attribute is the attribute of certificate request other than the extension
+// request and challenge password
+type Attribute struct {
+	ID     string   `json:"id" yaml:"id"`
+	Name   string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Values []string `json:"values" yaml:"values"`
+}
+
+// PKCS12Info is the machine-readable info of PKCS#12 file
+type PKCS12Info struct {
+	FriendlyName string             `json:"friendlyName,omitempty" yaml:"friendlyName,omitempty"`
+	PrivateKey   string             `json:"privateKey,omitempty" yaml:"privateKey,omitempty"`
+	Certificates []*CertificateInfo `json:"certificates" yaml:"certificates"`
+}
+
+// VerifyResult is the machine-readable result of verifying certificate, the
+// Checks are the report of every check and the details are in the other
+// fields
+type VerifyResult struct {
+	Passed      bool             `json:"passed" yaml:"passed"`
+	ExitCode    int              `json:"exitCode" yaml:"exitCode"`
+	Checks      []*ReportCheck   `json:"checks" yaml:"checks"`
+	Certificate *CertificateInfo `json:"certificate,omitempty" yaml:"certificate,omitempty"`
+	CA          *VerifyCheck     `json:"ca,omitempty" yaml:"ca,omitempty"`
+	Key         *VerifyCheck     `json:"key,omitempty" yaml:"key,omitempty"`
+	Hostname    *HostnameMatch   `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	Purpose     *PurposeCheck    `json:"purpose,omitempty" yaml:"purpose,omitempty"`
+	Chains      []*ChainInfo     `json:"chains,omitempty" yaml:"chains,omitempty"`
+	CRL         []*CRLCheck      `json:"crl,omitempty" yaml:"crl,omitempty"`
+	OCSP        *OCSPCheck       `json:"ocsp,omitempty" yaml:"ocsp,omitempty"`
+	CSR         *CSRCheck        `json:"csr,omitempty" yaml:"csr,omitempty"`
+	At          *time.Time       `json:"at,omitempty" yaml:"at,omitempty"`
+	Expiry      []*CertExpiry    `json:"expiry,omitempty" yaml:"expiry,omitempty"`
+}
+
+// VerifyCheck is the result of a verification check
+type VerifyCheck struct {
+	Verified bool   `json:"verified" yaml:"verified"`
+	Error    string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// NewVerifyCheck returns the check result of err, nil err means verified
+func NewVerifyCheck(err error) *VerifyCheck {
+	if err != nil {
+		return &VerifyCheck{Verified: false, Error: err.Error()}
+	}
+
+	return &VerifyCheck{Verified: true}
+}
+
+// NewCertificateInfo returns the machine-readable info of certificate
+func NewCertificateInfo(cert *x509.Certificate) *CertificateInfo {
+	info := &CertificateInfo{
+		Subject:            newName(cert.Subject),
+		Issuer:             newName(cert.Issuer),
+		SANs:               newSubjectAltNames(cert.DNSNames, cert.IPAddresses, cert.EmailAddresses, cert.URIs),
+		SerialNumber:       formatSerial(cert.SerialNumber),
+		IsCA:               cert.IsCA,
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		KeyUsages:          keyUsageNames(cert.KeyUsage),
+		PublicKeyAlgorithm: cert.PublicKeyAlgorithm.String(),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		Fingerprints:       GetFingerprints(cert),
+	}
+
+	for _, e := range cert.ExtKeyUsage {
+		// ignore unknown EKU
+		if value, ok := ekuActionToString[e]; ok {
+			info.ExtKeyUsages = append(info.ExtKeyUsages, value)
+		}
+	}
+	sort.Strings(info.ExtKeyUsages)
+
+	for _, e := range cert.Extensions {
+		info.Extensions = append(info.Extensions, Extension{
+			ID:       e.Id.String(),
+			Name:     extensionName(e.Id.String()),
+			Critical: e.Critical,
+		})
+	}
+
+	return info
+}
+
+// GetCertificateInfos returns the machine-readable info of PEM, DER or PKCS#7 certificates
+func GetCertificateInfos(certBytes []byte) ([]*CertificateInfo, error) {
+	certs, err := ParseCerts(certBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []*CertificateInfo
+	for _, cert := range certs {
+		infos = append(infos, NewCertificateInfo(cert))
+	}
+
+	return infos, nil
+}
+
+func newName(name pkix.Name) Name {
+	return Name{
+		DN:                 name.String(),
+		CommonName:         name.CommonName,
+		Country:            name.Country,
+		Province:           name.Province,
+		Locality:           name.Locality,
+		Organization:       name.Organization,
+		OrganizationalUnit: name.OrganizationalUnit,
+	}
+}
+
+func newSubjectAltNames(dnsNames []string, ips []net.IP, emails []string, uris []*url.URL) SubjectAltNames {
+	sans := SubjectAltNames{
+		DNSNames:       dnsNames,
+		EmailAddresses: emails,
+	}
+
+	for _, ip := range ips {
+		sans.IPAddresses = append(sans.IPAddresses, ip.String())
+	}
+	for _, uri := range uris {
+		sans.URIs = append(sans.URIs, uri.String())
+	}
+
+	return sans
+}
+
+// extensionName returns the name of known extension or empty string
+func extensionName(id string) string {
+	if name, ok := extensionIDToName[id]; ok {
+		return name
+	}
+	if name, ok := extraExtensionIDToName[id]; ok {
+		return name
+	}
+
+	return ""
+}
@@ -0,0 +1,61 @@
+package cert
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewCertificateInfo(t *testing.T) {
+	keyAlg, _ := NewKeyAlgorithm(KeyTypeECDSA, 0, "P-256")
+	key, err := keyAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+	certInfo, err := NewCertInfo(time.Hour, "CN=china/O=China Inc", "china.com,1.1.1.1", "digitalSignature,keyEncipherment", "serverAuth", false)
+	if err != nil {
+		t.Fatalf("failed NewCertInfo: %v", err)
+	}
+	certBytes, err := NewCert(certInfo, key)
+	if err != nil {
+		t.Fatalf("failed NewCert: %v", err)
+	}
+
+	infos, err := GetCertificateInfos(certBytes)
+	if err != nil {
+		t.Fatalf("failed GetCertificateInfos: %v", err)
+	}
+	if len(infos) != 1 {
+		t.Fatalf("failed GetCertificateInfos: expect 1 certificate, actual %d", len(infos))
+	}
+	info := infos[0]
+
+	if info.Subject.CommonName != "china" || !slices.Equal(info.Subject.Organization, []string{"China Inc"}) {
+		t.Errorf("failed NewCertificateInfo.Subject: %+v", info.Subject)
+	}
+	if !slices.Equal(info.SANs.DNSNames, []string{"china.com"}) || !slices.Equal(info.SANs.IPAddresses, []string{"1.1.1.1"}) {
+		t.Errorf("failed NewCertificateInfo.SANs: %+v", info.SANs)
+	}
+	if !slices.Equal(info.KeyUsages, []string{"Digital Signature", "Key Encipherment"}) {
+		t.Errorf("failed NewCertificateInfo.KeyUsages: %v", info.KeyUsages)
+	}
+	if !slices.Equal(info.ExtKeyUsages, []string{"TLS Web Server Authentication"}) {
+		t.Errorf("failed NewCertificateInfo.ExtKeyUsages: %v", info.ExtKeyUsages)
+	}
+	if info.PublicKeyAlgorithm != "ECDSA" || info.SignatureAlgorithm != "ECDSA-SHA256" {
+		t.Errorf("failed NewCertificateInfo algorithms: %s %s", info.PublicKeyAlgorithm, info.SignatureAlgorithm)
+	}
+
+	// the field names are part of the stable interface
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("failed json.Marshal: %v", err)
+	}
+	for _, field := range []string{`"subject"`, `"issuer"`, `"sans"`, `"serialNumber"`, `"notBefore"`, `"notAfter"`, `"keyUsages"`, `"extKeyUsages"`, `"fingerprints"`, `"extensions"`} {
+		if !strings.Contains(string(data), field) {
+			t.Errorf("failed json.Marshal: field %s not found", field)
+		}
+	}
+}
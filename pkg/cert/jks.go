@@ -0,0 +1,462 @@
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// The Java KeyStore(JKS) format, see sun.security.provider.JavaKeyStore
+
+const (
+	jksMagic          = 0xfeedfeed
+	jksVersion        = 2
+	jksPrivateKeyTag  = 1
+	jksTrustedCertTag = 2
+	jksCertType       = "X.509"
+	jksIntegrityText  = "Mighty Aphrodite"
+
+	// JKSPrivateKeyEntry is the type of the private key entry
+	JKSPrivateKeyEntry = "PrivateKeyEntry"
+	// JKSTrustedCertEntry is the type of the trusted certificate entry
+	JKSTrustedCertEntry = "trustedCertEntry"
+)
+
+// sun.security.provider.KeyProtector
+var oidJKSKeyProtector = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}
+
+var ErrKeyStoreTampered = errors.New("Keystore was tampered with, or password was incorrect")
+
+// JKSEntry is an entry of the Java KeyStore
+type JKSEntry struct {
+	Alias        string
+	Type         string
+	CreationDate time.Time
+	// Key is the private key of the private key entry, it is nil if the entry
+	// is not decrypted
+	Key crypto.PrivateKey
+	// Certs is the certificate chain of the private key entry, or the trusted
+	// certificate
+	Certs []*x509.Certificate
+
+	encryptedKey []byte
+}
+
+// NewJKSPrivateKeyEntry returns the private key entry, the certs are the
+// certificate chain of the private key
+func NewJKSPrivateKeyEntry(alias string, key crypto.PrivateKey, certs []*x509.Certificate) *JKSEntry {
+	return &JKSEntry{
+		Alias:        alias,
+		Type:         JKSPrivateKeyEntry,
+		CreationDate: time.Now(),
+		Key:          key,
+		Certs:        certs,
+	}
+}
+
+// NewJKSTrustedCertEntry returns the trusted certificate entry
+func NewJKSTrustedCertEntry(alias string, cert *x509.Certificate) *JKSEntry {
+	return &JKSEntry{
+		Alias:        alias,
+		Type:         JKSTrustedCertEntry,
+		CreationDate: time.Now(),
+		Certs:        []*x509.Certificate{cert},
+	}
+}
+
+// DecryptKey decrypts the private key of the private key entry
+func (e *JKSEntry) DecryptKey(password string) error {
+	if e.Type != JKSPrivateKeyEntry {
+		return fmt.Errorf("Keystore entry %s is not a private key entry", e.Alias)
+	}
+	if e.Key != nil {
+		return nil
+	}
+
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(e.encryptedKey, &info); err != nil {
+		return fmt.Errorf("Failed to parse keystore private key: %w", err)
+	}
+	if !info.Algo.Algorithm.Equal(oidJKSKeyProtector) {
+		return fmt.Errorf("Unsupported keystore key protection algorithm: %s", info.Algo.Algorithm)
+	}
+
+	der, err := jksUnprotectKey(info.EncryptedData, encodeBMPString(password))
+	if err != nil {
+		return fmt.Errorf("Failed to decrypt keystore private key %s: %w", e.Alias, err)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return fmt.Errorf("Failed to parse keystore private key %s: %w", e.Alias, err)
+	}
+	e.Key = key
+
+	return nil
+}
+
+// EncodeJKS encodes the entries to Java KeyStore, the password protects the
+// integrity of keystore and the keyPassword encrypts the private keys
+func EncodeJKS(entries []*JKSEntry, password, keyPassword string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writeUint32(&buf, jksMagic)
+	writeUint32(&buf, jksVersion)
+	writeUint32(&buf, uint32(len(entries)))
+
+	aliases := make(map[string]bool)
+	for _, entry := range entries {
+		// JKS aliases are case-insensitive and stored in lower case
+		alias := strings.ToLower(entry.Alias)
+		if aliases[alias] {
+			return nil, fmt.Errorf("Duplicated keystore alias: %s", alias)
+		}
+		aliases[alias] = true
+
+		switch entry.Type {
+		case JKSPrivateKeyEntry:
+			if entry.Key == nil || len(entry.Certs) == 0 {
+				return nil, fmt.Errorf("Keystore entry %s requires private key and certificate", entry.Alias)
+			}
+
+			der, err := x509.MarshalPKCS8PrivateKey(entry.Key)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to marshal private key: %w", err)
+			}
+
+			protected, err := jksProtectKey(der, encodeBMPString(keyPassword))
+			if err != nil {
+				return nil, err
+			}
+
+			encryptedKey, err := asn1.Marshal(encryptedPrivateKeyInfo{
+				Algo: pkix.AlgorithmIdentifier{
+					Algorithm:  oidJKSKeyProtector,
+					Parameters: asn1.NullRawValue,
+				},
+				EncryptedData: protected,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			writeUint32(&buf, jksPrivateKeyTag)
+			if err := writeJavaUTF(&buf, alias); err != nil {
+				return nil, err
+			}
+			writeUint64(&buf, uint64(entry.CreationDate.UnixMilli()))
+			writeUint32(&buf, uint32(len(encryptedKey)))
+			buf.Write(encryptedKey)
+			writeUint32(&buf, uint32(len(entry.Certs)))
+			for _, cert := range entry.Certs {
+				writeJKSCert(&buf, cert)
+			}
+		case JKSTrustedCertEntry:
+			if len(entry.Certs) != 1 {
+				return nil, fmt.Errorf("Keystore entry %s requires exactly one certificate", entry.Alias)
+			}
+
+			writeUint32(&buf, jksTrustedCertTag)
+			if err := writeJavaUTF(&buf, alias); err != nil {
+				return nil, err
+			}
+			writeUint64(&buf, uint64(entry.CreationDate.UnixMilli()))
+			writeJKSCert(&buf, entry.Certs[0])
+		default:
+			return nil, fmt.Errorf("Unsupported keystore entry type: %s", entry.Type)
+		}
+	}
+
+	buf.Write(jksDigest(buf.Bytes(), password))
+
+	return buf.Bytes(), nil
+}
+
+// DecodeJKS decodes the Java KeyStore, the integrity is not verified if the
+// password is empty. The private keys are not decrypted, use DecryptKey to
+// decrypt them.
+func DecodeJKS(data []byte, password string) ([]*JKSEntry, error) {
+	if len(data) < 12+sha1.Size {
+		return nil, fmt.Errorf("Failed to parse keystore: data too short")
+	}
+
+	content := data[:len(data)-sha1.Size]
+	if password != "" {
+		if subtle.ConstantTimeCompare(jksDigest(content, password), data[len(content):]) != 1 {
+			return nil, ErrKeyStoreTampered
+		}
+	}
+
+	r := bytes.NewReader(content)
+
+	var header struct {
+		Magic   uint32
+		Version uint32
+		Count   uint32
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, fmt.Errorf("Failed to parse keystore: %w", err)
+	}
+	if header.Magic != jksMagic {
+		return nil, fmt.Errorf("Failed to parse keystore: invalid magic number %#x", header.Magic)
+	}
+	if header.Version != 1 && header.Version != jksVersion {
+		return nil, fmt.Errorf("Failed to parse keystore: unsupported version %d", header.Version)
+	}
+
+	var entries []*JKSEntry
+	for i := uint32(0); i < header.Count; i++ {
+		entry, err := readJKSEntry(r, header.Version)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse keystore: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// IsJKS returns true if the data is a Java KeyStore
+func IsJKS(data []byte) bool {
+	return len(data) >= 4 && binary.BigEndian.Uint32(data) == jksMagic
+}
+
+func readJKSEntry(r *bytes.Reader, version uint32) (*JKSEntry, error) {
+	var tag uint32
+	if err := binary.Read(r, binary.BigEndian, &tag); err != nil {
+		return nil, err
+	}
+
+	alias, err := readJavaUTF(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var timestamp int64
+	if err := binary.Read(r, binary.BigEndian, &timestamp); err != nil {
+		return nil, err
+	}
+
+	entry := &JKSEntry{
+		Alias:        alias,
+		CreationDate: time.UnixMilli(timestamp),
+	}
+
+	switch tag {
+	case jksPrivateKeyTag:
+		entry.Type = JKSPrivateKeyEntry
+
+		entry.encryptedKey, err = readBytes(r)
+		if err != nil {
+			return nil, err
+		}
+
+		var count uint32
+		if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+			return nil, err
+		}
+		for i := uint32(0); i < count; i++ {
+			cert, err := readJKSCert(r, version)
+			if err != nil {
+				return nil, err
+			}
+			entry.Certs = append(entry.Certs, cert)
+		}
+	case jksTrustedCertTag:
+		entry.Type = JKSTrustedCertEntry
+
+		cert, err := readJKSCert(r, version)
+		if err != nil {
+			return nil, err
+		}
+		entry.Certs = append(entry.Certs, cert)
+	default:
+		return nil, fmt.Errorf("unsupported entry tag %d", tag)
+	}
+
+	return entry, nil
+}
+
+func readJKSCert(r *bytes.Reader, version uint32) (*x509.Certificate, error) {
+	// version 1 has no certificate type
+	if version == jksVersion {
+		certType, err := readJavaUTF(r)
+		if err != nil {
+			return nil, err
+		}
+		if certType != jksCertType {
+			return nil, fmt.Errorf("unsupported certificate type %s", certType)
+		}
+	}
+
+	der, err := readBytes(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(der)
+}
+
+func writeJKSCert(w *bytes.Buffer, cert *x509.Certificate) {
+	writeJavaUTF(w, jksCertType)
+	writeUint32(w, uint32(len(cert.Raw)))
+	w.Write(cert.Raw)
+}
+
+// jksDigest returns SHA-1 of password, "Mighty Aphrodite" and the content
+func jksDigest(content []byte, password string) []byte {
+	h := sha1.New()
+	h.Write(encodeBMPString(password))
+	h.Write([]byte(jksIntegrityText))
+	h.Write(content)
+	return h.Sum(nil)
+}
+
+// jksProtectKey encrypts the key with the SHA-1 based key stream, the
+// result is salt, encrypted key and SHA-1 of password and key
+func jksProtectKey(key, password []byte) ([]byte, error) {
+	salt := make([]byte, sha1.Size)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	encrypted := jksXORKey(key, salt, password)
+
+	h := sha1.New()
+	h.Write(password)
+	h.Write(key)
+
+	result := append(salt, encrypted...)
+	return h.Sum(result), nil
+}
+
+func jksUnprotectKey(protected, password []byte) ([]byte, error) {
+	if len(protected) < 2*sha1.Size {
+		return nil, fmt.Errorf("protected key too short")
+	}
+
+	salt := protected[:sha1.Size]
+	encrypted := protected[sha1.Size : len(protected)-sha1.Size]
+	check := protected[len(protected)-sha1.Size:]
+
+	key := jksXORKey(encrypted, salt, password)
+
+	h := sha1.New()
+	h.Write(password)
+	h.Write(key)
+	if subtle.ConstantTimeCompare(h.Sum(nil), check) != 1 {
+		return nil, fmt.Errorf("incorrect password")
+	}
+
+	return key, nil
+}
+
+func jksXORKey(data, salt, password []byte) []byte {
+	result := make([]byte, len(data))
+
+	digest := salt
+	for i := 0; i < len(data); i += sha1.Size {
+		h := sha1.New()
+		h.Write(password)
+		h.Write(digest)
+		digest = h.Sum(nil)
+
+		for j := 0; j < sha1.Size && i+j < len(data); j++ {
+			result[i+j] = data[i+j] ^ digest[j]
+		}
+	}
+
+	return result
+}
+
+func writeUint32(w *bytes.Buffer, v uint32) {
+	binary.Write(w, binary.BigEndian, v)
+}
+
+func writeUint64(w *bytes.Buffer, v uint64) {
+	binary.Write(w, binary.BigEndian, v)
+}
+
+// writeJavaUTF writes the string in Java modified UTF-8 with 2 bytes length
+func writeJavaUTF(w *bytes.Buffer, s string) error {
+	var b []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		switch {
+		case c != 0 && c < 0x80:
+			b = append(b, byte(c))
+		case c < 0x800:
+			b = append(b, byte(0xc0|c>>6), byte(0x80|c&0x3f))
+		default:
+			b = append(b, byte(0xe0|c>>12), byte(0x80|(c>>6)&0x3f), byte(0x80|c&0x3f))
+		}
+	}
+
+	if len(b) > 0xffff {
+		return fmt.Errorf("String too long: %d", len(b))
+	}
+
+	binary.Write(w, binary.BigEndian, uint16(len(b)))
+	w.Write(b)
+
+	return nil
+}
+
+func readJavaUTF(r *bytes.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "", err
+	}
+
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+
+	var u []uint16
+	for i := 0; i < len(b); {
+		switch {
+		case b[i] < 0x80:
+			u = append(u, uint16(b[i]))
+			i++
+		case b[i]&0xe0 == 0xc0 && i+1 < len(b):
+			u = append(u, uint16(b[i]&0x1f)<<6|uint16(b[i+1]&0x3f))
+			i += 2
+		case b[i]&0xf0 == 0xe0 && i+2 < len(b):
+			u = append(u, uint16(b[i]&0x0f)<<12|uint16(b[i+1]&0x3f)<<6|uint16(b[i+2]&0x3f))
+			i += 3
+		default:
+			return "", fmt.Errorf("invalid modified UTF-8 string")
+		}
+	}
+
+	return string(utf16.Decode(u)), nil
+}
+
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	if int64(length) > int64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
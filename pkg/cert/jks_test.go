@@ -0,0 +1,109 @@
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEncodeJKS(t *testing.T) {
+	keyAlg, _ := NewKeyAlgorithm(KeyTypeECDSA, 0, "P-256")
+	key, err := keyAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+	certInfo, err := NewCertInfo(time.Hour, "CN=china", "china.com", "digitalSignature", "serverAuth", false)
+	if err != nil {
+		t.Fatalf("failed NewCertInfo: %v", err)
+	}
+	certBytes, err := NewCert(certInfo, key)
+	if err != nil {
+		t.Fatalf("failed NewCert: %v", err)
+	}
+	cert, _ := ParseCert(certBytes)
+
+	entries := []*JKSEntry{
+		NewJKSPrivateKeyEntry("China", key, []*x509.Certificate{cert}),
+		NewJKSTrustedCertEntry("ca", cert),
+	}
+
+	jksBytes, err := EncodeJKS(entries, "storepass", "keypass")
+	if err != nil {
+		t.Fatalf("failed EncodeJKS: %v", err)
+	}
+
+	if !IsJKS(jksBytes) {
+		t.Errorf("failed IsJKS")
+	}
+
+	if _, err := DecodeJKS(jksBytes, "wrong"); !errors.Is(err, ErrKeyStoreTampered) {
+		t.Errorf("failed DecodeJKS with wrong password:\n\tactual: %v\n\texpect: %v\n", err, ErrKeyStoreTampered)
+	}
+
+	decoded, err := DecodeJKS(jksBytes, "storepass")
+	if err != nil {
+		t.Fatalf("failed DecodeJKS: %v", err)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("failed DecodeJKS: expect 2 entries, actual %d", len(decoded))
+	}
+
+	var tests = []struct {
+		alias     string
+		entryType string
+	}{
+		{alias: "china", entryType: JKSPrivateKeyEntry},
+		{alias: "ca", entryType: JKSTrustedCertEntry},
+	}
+
+	for i, test := range tests {
+		entry := decoded[i]
+		if entry.Alias != test.alias || entry.Type != test.entryType {
+			t.Errorf("failed DecodeJKS entry:\n\tactual: %s %s\n\texpect: %s %s\n", entry.Alias, entry.Type, test.alias, test.entryType)
+		}
+		if len(entry.Certs) != 1 || !entry.Certs[0].Equal(cert) {
+			t.Errorf("failed DecodeJKS entry %s: certificate mismatch", entry.Alias)
+		}
+	}
+
+	if err := decoded[0].DecryptKey("storepass"); err == nil {
+		t.Errorf("failed DecryptKey: expect error for wrong password")
+	}
+	if err := decoded[0].DecryptKey("keypass"); err != nil {
+		t.Fatalf("failed DecryptKey: %v", err)
+	}
+	if signer, ok := decoded[0].Key.(crypto.Signer); !ok || !PublicKeyEqual(signer.Public(), key.Public()) {
+		t.Errorf("failed DecryptKey: public key mismatch")
+	}
+
+	// the integrity is not verified without password
+	if _, err := DecodeJKS(jksBytes, ""); err != nil {
+		t.Errorf("failed DecodeJKS without password: %v", err)
+	}
+
+	if _, err := EncodeJKS([]*JKSEntry{NewJKSTrustedCertEntry("CA", cert), NewJKSTrustedCertEntry("ca", cert)}, "storepass", ""); err == nil {
+		t.Errorf("failed EncodeJKS: expect error for duplicated alias")
+	}
+}
+
+func TestJavaUTF(t *testing.T) {
+	var tests = []string{"", "china", "中国", "a\x00b", "😀"}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := writeJavaUTF(&buf, test); err != nil {
+			t.Fatalf("failed writeJavaUTF: %v", err)
+		}
+
+		actual, err := readJavaUTF(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("failed readJavaUTF: %v", err)
+		}
+		if actual != test {
+			t.Errorf("failed Java UTF:\n\tactual: %q\n\texpect: %q\n", actual, test)
+		}
+	}
+}
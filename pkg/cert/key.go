@@ -0,0 +1,166 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+const (
+	KeyTypeRSA     = "rsa"
+	KeyTypeECDSA   = "ecdsa"
+	KeyTypeEd25519 = "ed25519"
+)
+
+var curveNameToCurve = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// KeyAlgorithm describes how a new private key is generated
+type KeyAlgorithm struct {
+	Type    string
+	RSASize int
+	Curve   elliptic.Curve
+}
+
+func NewKeyAlgorithm(keyType string, rsaKeySize int, curve string) (*KeyAlgorithm, error) {
+	keyAlg := &KeyAlgorithm{}
+
+	switch strings.ToLower(strings.TrimSpace(keyType)) {
+	case KeyTypeRSA:
+		if rsaKeySize < 1024 {
+			return nil, fmt.Errorf("Invalid RSA key size: %d", rsaKeySize)
+		}
+		keyAlg.Type = KeyTypeRSA
+		keyAlg.RSASize = rsaKeySize
+	case KeyTypeECDSA, "ec":
+		c, err := getCurve(curve)
+		if err != nil {
+			return nil, err
+		}
+		keyAlg.Type = KeyTypeECDSA
+		keyAlg.Curve = c
+	case KeyTypeEd25519:
+		keyAlg.Type = KeyTypeEd25519
+	default:
+		return nil, fmt.Errorf("Invalid key type: %s", keyType)
+	}
+
+	return keyAlg, nil
+}
+
+// GenerateKey generates a new private key with the key algorithm
+func (a *KeyAlgorithm) GenerateKey() (crypto.Signer, error) {
+	switch a.Type {
+	case KeyTypeRSA:
+		return rsa.GenerateKey(rand.Reader, a.RSASize)
+	case KeyTypeECDSA:
+		return ecdsa.GenerateKey(a.Curve, rand.Reader)
+	case KeyTypeEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("Invalid key type: %s", a.Type)
+}
+
+// EncodeKey encodes the private key to PEM, RSA keys are in PKCS#1,
+// ECDSA keys are in SEC 1 and the others are in PKCS#8
+func EncodeKey(key crypto.Signer) ([]byte, error) {
+	var block *pem.Block
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: RSAKeyBlockType, Bytes: x509.MarshalPKCS1PrivateKey(k)}
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: ECKEYBlockType, Bytes: der}
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: PrivateKeyBlockType, Bytes: der}
+	}
+
+	return pem.EncodeToMemory(block), nil
+}
+
+// ParseSigner parses the PEM private key and makes sure it can sign certificates,
+// the passphrase is only used for encrypted private key
+func ParseSigner(keyBytes, passphrase []byte) (crypto.Signer, error) {
+	key, err := ParseKeyWithPassphrase(keyBytes, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("Unsupported private key type: %T", key)
+	}
+
+	return signer, nil
+}
+
+// GetKeyType returns the key type of the public key
+func GetKeyType(pub crypto.PublicKey) string {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return KeyTypeRSA
+	case *ecdsa.PublicKey:
+		return KeyTypeECDSA
+	case ed25519.PublicKey:
+		return KeyTypeEd25519
+	}
+
+	return "unknown"
+}
+
+// EncodeEncryptedKey encodes the private key to PEM encrypted PKCS#8
+func EncodeEncryptedKey(key crypto.Signer, passphrase []byte, kdf string) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := EncryptPKCS8PrivateKey(der, passphrase, kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: EncryptedPrivateKeyBlockType, Bytes: encrypted}), nil
+}
+
+// PublicKeyEqual reports whether the two public keys are the same
+func PublicKeyEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return false
+	}
+
+	return key.Equal(b)
+}
+
+func getCurve(curve string) (elliptic.Curve, error) {
+	for name, c := range curveNameToCurve {
+		if strings.EqualFold(strings.TrimSpace(curve), name) {
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Invalid curve: %s", curve)
+}
@@ -0,0 +1,134 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/pem"
+	"testing"
+	"time"
+)
+
+func TestNewKeyAlgorithm(t *testing.T) {
+	var tests = []struct {
+		keyType   string
+		size      int
+		curve     string
+		blockType string
+		invalid   bool
+	}{
+		{keyType: "rsa", size: 2048, curve: "P-256", blockType: RSAKeyBlockType},
+		{keyType: "RSA", size: 512, invalid: true},
+		{keyType: "ecdsa", size: 2048, curve: "P-256", blockType: ECKEYBlockType},
+		{keyType: "ecdsa", size: 2048, curve: "p-384", blockType: ECKEYBlockType},
+		{keyType: "ecdsa", curve: "P-224", invalid: true},
+		{keyType: "ed25519", blockType: PrivateKeyBlockType},
+		{keyType: "dsa", invalid: true},
+	}
+
+	for _, test := range tests {
+		keyAlg, err := NewKeyAlgorithm(test.keyType, test.size, test.curve)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("expect error for key type %s", test.keyType)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed NewKeyAlgorithm: %v", err)
+		}
+
+		key, err := keyAlg.GenerateKey()
+		if err != nil {
+			t.Fatalf("failed GenerateKey: %v", err)
+		}
+
+		keyBytes, err := EncodeKey(key)
+		if err != nil {
+			t.Fatalf("failed EncodeKey: %v", err)
+		}
+
+		block, _ := pem.Decode(keyBytes)
+		if block == nil || block.Type != test.blockType {
+			t.Errorf("failed EncodeKey block type:\n\tactual: %v\n\texpect: %v\n", block, test.blockType)
+		}
+
+		parsed, err := ParseKey(keyBytes)
+		if err != nil {
+			t.Fatalf("failed ParseKey: %v", err)
+		}
+
+		switch keyAlg.Type {
+		case KeyTypeRSA:
+			if k, ok := parsed.(*rsa.PrivateKey); !ok || k.N.BitLen() != test.size {
+				t.Errorf("failed RSA key: %T", parsed)
+			}
+		case KeyTypeECDSA:
+			if k, ok := parsed.(*ecdsa.PrivateKey); !ok || k.Curve != keyAlg.Curve {
+				t.Errorf("failed ECDSA key: %T", parsed)
+			}
+		case KeyTypeEd25519:
+			if _, ok := parsed.(ed25519.PrivateKey); !ok {
+				t.Errorf("failed Ed25519 key: %T", parsed)
+			}
+		}
+	}
+}
+
+// the renewed certificate with the reused private key keeps the same SPKI
+func TestParseSignerRenew(t *testing.T) {
+	var tests = []struct {
+		keyType    string
+		curve      string
+		passphrase []byte
+	}{
+		{keyType: KeyTypeRSA},
+		{keyType: KeyTypeECDSA, curve: "P-384"},
+		{keyType: KeyTypeEd25519},
+		{keyType: KeyTypeECDSA, curve: "P-256", passphrase: []byte("secret")},
+	}
+
+	for _, test := range tests {
+		keyAlg, _ := NewKeyAlgorithm(test.keyType, 2048, test.curve)
+		key, err := keyAlg.GenerateKey()
+		if err != nil {
+			t.Fatalf("failed GenerateKey: %v", err)
+		}
+
+		var keyBytes []byte
+		if test.passphrase != nil {
+			keyBytes, err = EncodeEncryptedKey(key, test.passphrase, KDFPBKDF2)
+		} else {
+			keyBytes, err = EncodeKey(key)
+		}
+		if err != nil {
+			t.Fatalf("failed to encode key: %v", err)
+		}
+
+		certInfo, _ := NewCertInfo(time.Hour, "CN=renew.com", "renew.com", "", "", false)
+		certBytes, err := NewCert(certInfo, key)
+		if err != nil {
+			t.Fatalf("failed NewCert: %v", err)
+		}
+		cert, _ := ParseCert(certBytes)
+
+		reused, err := ParseSigner(keyBytes, test.passphrase)
+		if err != nil {
+			t.Fatalf("failed ParseSigner %s: %v", test.keyType, err)
+		}
+		if actual := GetKeyType(reused.Public()); actual != test.keyType {
+			t.Errorf("failed GetKeyType:\n\tactual: %s\n\texpect: %s\n", actual, test.keyType)
+		}
+
+		renewedBytes, err := NewCert(certInfo, reused)
+		if err != nil {
+			t.Fatalf("failed NewCert with reused key: %v", err)
+		}
+		renewed, _ := ParseCert(renewedBytes)
+
+		if !bytes.Equal(renewed.RawSubjectPublicKeyInfo, cert.RawSubjectPublicKeyInfo) {
+			t.Errorf("failed to keep SPKI of %s key", test.keyType)
+		}
+	}
+}
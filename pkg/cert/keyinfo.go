@@ -0,0 +1,342 @@
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+)
+
+const (
+	PublicKeyBlockType    = "PUBLIC KEY"
+	RSAPublicKeyBlockType = "RSA PUBLIC KEY"
+	ECParamsBlockType     = "EC PARAMETERS"
+
+	KeyInfoPrivate = "private"
+	KeyInfoPublic  = "public"
+)
+
+// KeyInfo is the machine-readable info of private key or public key
+type KeyInfo struct {
+	Type            string       `json:"type" yaml:"type"`
+	Algorithm       string       `json:"algorithm" yaml:"algorithm"`
+	Size            int          `json:"size" yaml:"size"`
+	Curve           string       `json:"curve,omitempty" yaml:"curve,omitempty"`
+	PublicKeySHA256 string       `json:"publicKeySHA256" yaml:"publicKeySHA256"`
+	SPKISHA256      string       `json:"spkiSHA256" yaml:"spkiSHA256"`
+	Check           *VerifyCheck `json:"check,omitempty" yaml:"check,omitempty"`
+}
+
+// IsKeyBlockType returns true if the PEM block type is private key or public key
+func IsKeyBlockType(blockType string) bool {
+	switch blockType {
+	case RSAKeyBlockType, ECKEYBlockType, PrivateKeyBlockType, EncryptedPrivateKeyBlockType,
+		PublicKeyBlockType, RSAPublicKeyBlockType:
+		return true
+	}
+
+	return false
+}
+
+// ParsePublicKey parses the PEM or DER public key, both PKIX and PKCS#1 RSA
+// public keys are supported
+func ParsePublicKey(pubBytes []byte) (crypto.PublicKey, error) {
+	pemBytes, err := ToPEM(pubBytes, "", PublicKeyBlockType)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse public key: %w", err)
+	}
+
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("Failed to parse public key")
+	}
+
+	var pub crypto.PublicKey
+	switch block.Type {
+	case PublicKeyBlockType:
+		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case RSAPublicKeyBlockType:
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("Not a Public Key: %s", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse public key: %w", err)
+	}
+
+	return pub, nil
+}
+
+// ParseKeyInfo parses the private key or public key and returns its info,
+// the passphrase is only used for encrypted private key
+func ParseKeyInfo(keyBytes, passphrase []byte) (*KeyInfo, error) {
+	blockType := DetectDERBlockType(keyBytes)
+	if block := decodeKeyBlock(keyBytes); block != nil {
+		blockType = block.Type
+	}
+
+	if blockType == PublicKeyBlockType || blockType == RSAPublicKeyBlockType {
+		pub, err := ParsePublicKey(keyBytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewPublicKeyInfo(pub)
+	}
+
+	blockType, der, err := decodePrivateKey(keyBytes, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := parsePrivateKeyDER(blockType, der)
+	if err != nil {
+		// the RSA private key is validated by the parser, the inconsistent
+		// key is reported by the check instead
+		pub := parseStoredRSAPublicKey(der)
+		if pub == nil {
+			return nil, fmt.Errorf("Failed to parse private key: %w", err)
+		}
+		info, infoErr := NewPublicKeyInfo(pub)
+		if infoErr != nil {
+			return nil, infoErr
+		}
+		info.Type = KeyInfoPrivate
+		info.Check = NewVerifyCheck(fmt.Errorf("Invalid RSA private key: %w", err))
+		return info, nil
+	}
+
+	key, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("Unsupported private key type: %T", parsed)
+	}
+
+	info, err := NewPrivateKeyInfo(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// the parsers derive the public key from the private key, so the stored
+	// public key is checked separately
+	if info.Check.Verified {
+		info.Check = NewVerifyCheck(checkStoredPublicKey(blockType, der, key))
+	}
+
+	return info, nil
+}
+
+// NewPublicKeyInfo returns the machine-readable info of public key
+func NewPublicKeyInfo(pub crypto.PublicKey) (*KeyInfo, error) {
+	spki, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to marshal public key: %w", err)
+	}
+	sum := sha256.Sum256(spki)
+
+	info := &KeyInfo{
+		Type:            KeyInfoPublic,
+		PublicKeySHA256: formatHex(sum[:]),
+		SPKISHA256:      GetSPKIPin(spki),
+	}
+
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		info.Algorithm = x509.RSA.String()
+		info.Size = k.N.BitLen()
+	case *ecdsa.PublicKey:
+		info.Algorithm = x509.ECDSA.String()
+		info.Size = k.Curve.Params().BitSize
+		info.Curve = k.Curve.Params().Name
+	case ed25519.PublicKey:
+		info.Algorithm = x509.Ed25519.String()
+		info.Size = 256
+	default:
+		return nil, fmt.Errorf("Unsupported public key type: %T", pub)
+	}
+
+	return info, nil
+}
+
+// NewPrivateKeyInfo returns the machine-readable info of private key,
+// including the result of the key consistency check
+func NewPrivateKeyInfo(key crypto.Signer) (*KeyInfo, error) {
+	info, err := NewPublicKeyInfo(key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	info.Type = KeyInfoPrivate
+	info.Check = NewVerifyCheck(CheckPrivateKey(key))
+
+	return info, nil
+}
+
+// CheckPrivateKey checks the private key is consistent with its public key
+func CheckPrivateKey(key crypto.Signer) error {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if err := k.Validate(); err != nil {
+			return fmt.Errorf("Invalid RSA private key: %w", err)
+		}
+	case *ecdsa.PrivateKey:
+		// the public point must be on curve and equal to D*G
+		if !isECDHCurve(k.Curve) {
+			if !k.Curve.IsOnCurve(k.X, k.Y) {
+				return fmt.Errorf("Invalid ECDSA public key: point not on curve %s", k.Curve.Params().Name)
+			}
+			if x, y := k.Curve.ScalarBaseMult(k.D.Bytes()); x.Cmp(k.X) != 0 || y.Cmp(k.Y) != 0 {
+				return fmt.Errorf("Invalid ECDSA private key: public key does not match private key")
+			}
+			break
+		}
+		priv, err := k.ECDH()
+		if err != nil {
+			return fmt.Errorf("Invalid ECDSA private key: %w", err)
+		}
+		pub, err := k.PublicKey.ECDH()
+		if err != nil {
+			return fmt.Errorf("Invalid ECDSA public key: %w", err)
+		}
+		if !priv.PublicKey().Equal(pub) {
+			return fmt.Errorf("Invalid ECDSA private key: public key does not match private key")
+		}
+	case ed25519.PrivateKey:
+		if len(k) != ed25519.PrivateKeySize {
+			return fmt.Errorf("Invalid Ed25519 private key: bad key length %d", len(k))
+		}
+		if !ed25519.NewKeyFromSeed(k.Seed()).Public().(ed25519.PublicKey).Equal(k.Public()) {
+			return fmt.Errorf("Invalid Ed25519 private key: public key does not match private key")
+		}
+	default:
+		return fmt.Errorf("Unsupported private key type: %T", key)
+	}
+
+	return nil
+}
+
+// isECDHCurve returns true if the curve is supported by crypto/ecdh, P-224 is not
+func isECDHCurve(curve elliptic.Curve) bool {
+	switch curve {
+	case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		return true
+	}
+
+	return false
+}
+
+// the ASN.1 structures of private keys, only to read the stored public keys
+// which are ignored by the parsers of crypto/x509
+type pkcs8PrivateKey struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+	Attributes asn1.RawValue  `asn1:"optional,tag:0"`
+	PublicKey  asn1.BitString `asn1:"optional,tag:1"`
+}
+
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+type pkcs1PrivateKey struct {
+	Version          int
+	N                *big.Int
+	E                int
+	D                *big.Int
+	P                *big.Int
+	Q                *big.Int
+	Dp               *big.Int      `asn1:"optional"`
+	Dq               *big.Int      `asn1:"optional"`
+	Qinv             *big.Int      `asn1:"optional"`
+	AdditionalPrimes asn1.RawValue `asn1:"optional"`
+}
+
+var (
+	oidPublicKeyRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+)
+
+// checkStoredPublicKey checks the public key stored in the SEC 1 or PKCS#8
+// private key matches the one derived from the private key
+func checkStoredPublicKey(blockType string, der []byte, key crypto.Signer) error {
+	if blockType == PrivateKeyBlockType {
+		var p8 pkcs8PrivateKey
+		if _, err := asn1.Unmarshal(der, &p8); err != nil {
+			return nil
+		}
+		if !p8.Algo.Algorithm.Equal(oidPublicKeyECDSA) {
+			if k, ok := key.(ed25519.PrivateKey); ok && len(p8.PublicKey.Bytes) > 0 &&
+				!bytes.Equal(p8.PublicKey.Bytes, k.Public().(ed25519.PublicKey)) {
+				return fmt.Errorf("Invalid Ed25519 private key: stored public key does not match private key")
+			}
+			return nil
+		}
+		der = p8.PrivateKey
+	} else if blockType != ECKEYBlockType {
+		return nil
+	}
+
+	k, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil
+	}
+	var ec ecPrivateKey
+	if _, err := asn1.Unmarshal(der, &ec); err != nil || len(ec.PublicKey.Bytes) == 0 {
+		return nil
+	}
+
+	stored := ec.PublicKey.Bytes
+	expect := elliptic.Marshal(k.Curve, k.X, k.Y)
+	if stored[0] != 4 {
+		expect = elliptic.MarshalCompressed(k.Curve, k.X, k.Y)
+	}
+	if !bytes.Equal(stored, expect) {
+		return fmt.Errorf("Invalid ECDSA private key: stored public key does not match private key")
+	}
+
+	return nil
+}
+
+// parseStoredRSAPublicKey returns the public key stored in the PKCS#1 or
+// PKCS#8 RSA private key, or nil if it is not an RSA private key
+func parseStoredRSAPublicKey(der []byte) *rsa.PublicKey {
+	var p8 pkcs8PrivateKey
+	if _, err := asn1.Unmarshal(der, &p8); err == nil {
+		if !p8.Algo.Algorithm.Equal(oidPublicKeyRSA) {
+			return nil
+		}
+		der = p8.PrivateKey
+	}
+
+	var p1 pkcs1PrivateKey
+	if rest, err := asn1.Unmarshal(der, &p1); err != nil || len(rest) > 0 {
+		return nil
+	}
+	if p1.N == nil || p1.N.Sign() <= 0 || p1.E <= 0 {
+		return nil
+	}
+
+	return &rsa.PublicKey{N: p1.N, E: p1.E}
+}
+
+// decodeKeyBlock decodes the first key block of PEM data, the other blocks
+// like EC PARAMETERS or certificates of a combined PEM file are skipped
+func decodeKeyBlock(pemBytes []byte) *pem.Block {
+	block, rest := pem.Decode(pemBytes)
+	for block != nil && !IsKeyBlockType(block.Type) {
+		block, rest = pem.Decode(rest)
+	}
+
+	return block
+}
@@ -0,0 +1,238 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseKeyInfo(t *testing.T) {
+	var tests = []struct {
+		keyType string
+		size    int
+		curve   string
+		expect  KeyInfo
+	}{
+		{keyType: KeyTypeRSA, size: 2048, expect: KeyInfo{Algorithm: "RSA", Size: 2048}},
+		{keyType: KeyTypeECDSA, curve: "P-384", expect: KeyInfo{Algorithm: "ECDSA", Size: 384, Curve: "P-384"}},
+		{keyType: KeyTypeEd25519, expect: KeyInfo{Algorithm: "Ed25519", Size: 256}},
+	}
+
+	for _, test := range tests {
+		keyAlg, err := NewKeyAlgorithm(test.keyType, test.size, test.curve)
+		if err != nil {
+			t.Fatalf("failed NewKeyAlgorithm: %v", err)
+		}
+		key, err := keyAlg.GenerateKey()
+		if err != nil {
+			t.Fatalf("failed GenerateKey: %v", err)
+		}
+		keyBytes, err := EncodeKey(key)
+		if err != nil {
+			t.Fatalf("failed EncodeKey: %v", err)
+		}
+		spki, err := x509.MarshalPKIXPublicKey(key.Public())
+		if err != nil {
+			t.Fatalf("failed MarshalPKIXPublicKey: %v", err)
+		}
+		pubBytes := pem.EncodeToMemory(&pem.Block{Type: PublicKeyBlockType, Bytes: spki})
+
+		var inputs = []struct {
+			data    []byte
+			private bool
+		}{
+			{data: keyBytes, private: true},
+			{data: pubBytes},
+			{data: spki},
+		}
+
+		for _, input := range inputs {
+			info, err := ParseKeyInfo(input.data, nil)
+			if err != nil {
+				t.Fatalf("failed ParseKeyInfo: %v", err)
+			}
+			if info.Algorithm != test.expect.Algorithm || info.Size != test.expect.Size || info.Curve != test.expect.Curve {
+				t.Errorf("failed ParseKeyInfo:\n\tactual: %+v\n\texpect: %+v\n", info, test.expect)
+			}
+			if info.SPKISHA256 != GetSPKIPin(spki) {
+				t.Errorf("failed ParseKeyInfo.SPKISHA256:\n\tactual: %s\n\texpect: %s\n", info.SPKISHA256, GetSPKIPin(spki))
+			}
+
+			if input.private && (info.Type != KeyInfoPrivate || info.Check == nil || !info.Check.Verified) {
+				t.Errorf("failed ParseKeyInfo private key: %+v %+v", info, info.Check)
+			}
+			if !input.private && (info.Type != KeyInfoPublic || info.Check != nil) {
+				t.Errorf("failed ParseKeyInfo public key: %+v", info)
+			}
+		}
+	}
+}
+
+func TestParsePublicKeyPKCS1(t *testing.T) {
+	keyAlg, _ := NewKeyAlgorithm(KeyTypeRSA, 2048, "")
+	key, err := keyAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+	der := x509.MarshalPKCS1PublicKey(key.Public().(*rsa.PublicKey))
+
+	for _, data := range [][]byte{der, pem.EncodeToMemory(&pem.Block{Type: RSAPublicKeyBlockType, Bytes: der})} {
+		pub, err := ParsePublicKey(data)
+		if err != nil {
+			t.Fatalf("failed ParsePublicKey: %v", err)
+		}
+		if !PublicKeyEqual(pub, key.Public()) {
+			t.Errorf("failed ParsePublicKey: public key mismatch")
+		}
+	}
+}
+
+func TestCheckPrivateKey(t *testing.T) {
+	rsaAlg, _ := NewKeyAlgorithm(KeyTypeRSA, 2048, "")
+	rsaKey, _ := rsaAlg.GenerateKey()
+	ecAlg, _ := NewKeyAlgorithm(KeyTypeECDSA, 0, "P-256")
+	ecKey, _ := ecAlg.GenerateKey()
+	otherECKey, _ := ecAlg.GenerateKey()
+	edAlg, _ := NewKeyAlgorithm(KeyTypeEd25519, 0, "")
+	edKey, _ := edAlg.GenerateKey()
+
+	badRSAKey := *rsaKey.(*rsa.PrivateKey)
+	badRSAKey.D = new(big.Int).Add(badRSAKey.D, big.NewInt(2))
+
+	badECKey := *ecKey.(*ecdsa.PrivateKey)
+	badECKey.PublicKey = otherECKey.(*ecdsa.PrivateKey).PublicKey
+
+	badEdKey := make(ed25519.PrivateKey, ed25519.PrivateKeySize)
+	copy(badEdKey, edKey.(ed25519.PrivateKey))
+	badEdKey[ed25519.PrivateKeySize-1] ^= 0xff
+
+	var tests = []struct {
+		name   string
+		key    crypto.Signer
+		expect bool
+	}{
+		{name: "rsa", key: rsaKey, expect: true},
+		{name: "ecdsa", key: ecKey, expect: true},
+		{name: "ed25519", key: edKey, expect: true},
+		{name: "bad rsa", key: &badRSAKey, expect: false},
+		{name: "bad ecdsa", key: &badECKey, expect: false},
+		{name: "bad ed25519", key: badEdKey, expect: false},
+	}
+
+	for _, test := range tests {
+		info, err := NewPrivateKeyInfo(test.key)
+		if err != nil {
+			t.Fatalf("failed NewPrivateKeyInfo %s: %v", test.name, err)
+		}
+		if info.Check.Verified != test.expect {
+			t.Errorf("failed CheckPrivateKey %s:\n\tactual: %v %s\n\texpect: %v\n", test.name, info.Check.Verified, info.Check.Error, test.expect)
+		}
+	}
+}
+
+func TestParseKeyInfoTampered(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherECKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p224Key, _ := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	otherP224Key, _ := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherEdPub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	// swapStoredPublicKey replaces the public key stored in the SEC 1 key
+	swapStoredPublicKey := func(key, other *ecdsa.PrivateKey) []byte {
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("failed MarshalECPrivateKey: %v", err)
+		}
+		var ec ecPrivateKey
+		if _, err := asn1.Unmarshal(der, &ec); err != nil {
+			t.Fatalf("failed Unmarshal: %v", err)
+		}
+		pub := elliptic.Marshal(other.Curve, other.X, other.Y)
+		ec.PublicKey = asn1.BitString{Bytes: pub, BitLength: len(pub) * 8}
+		der, _ = asn1.Marshal(ec)
+		return der
+	}
+	// wrapPKCS8 wraps the private key DER into PKCS#8
+	wrapPKCS8 := func(key crypto.Signer, der []byte) []byte {
+		p8Bytes, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("failed MarshalPKCS8PrivateKey: %v", err)
+		}
+		var p8 pkcs8PrivateKey
+		if _, err := asn1.Unmarshal(p8Bytes, &p8); err != nil {
+			t.Fatalf("failed Unmarshal: %v", err)
+		}
+		p8.PrivateKey = der
+		p8Bytes, _ = asn1.Marshal(p8)
+		return p8Bytes
+	}
+
+	badECDER := swapStoredPublicKey(ecKey, otherECKey)
+	p224DER, _ := x509.MarshalECPrivateKey(p224Key)
+
+	var p1 pkcs1PrivateKey
+	if _, err := asn1.Unmarshal(x509.MarshalPKCS1PrivateKey(rsaKey), &p1); err != nil {
+		t.Fatalf("failed Unmarshal: %v", err)
+	}
+	p1.D = new(big.Int).Add(p1.D, big.NewInt(2))
+	badRSADER, _ := asn1.Marshal(p1)
+
+	// Ed25519 PKCS#8 v2 with the public key
+	edDER, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	var p8 pkcs8PrivateKey
+	asn1.Unmarshal(edDER, &p8)
+	p8.Version = 1
+	p8.PublicKey = asn1.BitString{Bytes: otherEdPub, BitLength: len(otherEdPub) * 8}
+	badEdDER, _ := asn1.Marshal(p8)
+
+	var tests = []struct {
+		name      string
+		blockType string
+		der       []byte
+		errMsg    string
+	}{
+		{name: "ecdsa sec1", blockType: ECKEYBlockType, der: badECDER, errMsg: "stored public key does not match"},
+		{name: "ecdsa pkcs8", blockType: PrivateKeyBlockType, der: wrapPKCS8(ecKey, badECDER), errMsg: "stored public key does not match"},
+		{name: "p-224", blockType: ECKEYBlockType, der: p224DER},
+		{name: "p-224 sec1", blockType: ECKEYBlockType, der: swapStoredPublicKey(p224Key, otherP224Key), errMsg: "stored public key does not match"},
+		{name: "rsa pkcs1", blockType: RSAKeyBlockType, der: badRSADER, errMsg: "Invalid RSA private key"},
+		{name: "rsa pkcs8", blockType: PrivateKeyBlockType, der: wrapPKCS8(rsaKey, badRSADER), errMsg: "Invalid RSA private key"},
+		{name: "ed25519 pkcs8", blockType: PrivateKeyBlockType, der: badEdDER, errMsg: "stored public key does not match"},
+	}
+
+	dir := t.TempDir()
+	for _, test := range tests {
+		file := filepath.Join(dir, strings.ReplaceAll(test.name, " ", "-")+".key")
+		if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: test.blockType, Bytes: test.der}), 0600); err != nil {
+			t.Fatalf("failed WriteFile: %v", err)
+		}
+		keyBytes, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("failed ReadFile: %v", err)
+		}
+
+		for _, data := range [][]byte{keyBytes, test.der} {
+			info, err := ParseKeyInfo(data, nil)
+			if err != nil {
+				t.Errorf("failed ParseKeyInfo %s: %v", test.name, err)
+				continue
+			}
+			if info.Check.Verified != (test.errMsg == "") || !strings.Contains(info.Check.Error, test.errMsg) {
+				t.Errorf("failed ParseKeyInfo %s:\n\tactual: %v %s\n\texpect: %s\n", test.name, info.Check.Verified, info.Check.Error, test.errMsg)
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	OCSPStatusGood    = "good"
+	OCSPStatusRevoked = "revoked"
+	OCSPStatusUnknown = "unknown"
+)
+
+// OCSPCheck is the OCSP status of certificate, the Signer is the delegated
+// responder certificate and empty if the response is signed by the issuer
+type OCSPCheck struct {
+	Subject      string     `json:"subject" yaml:"subject"`
+	SerialNumber string     `json:"serialNumber" yaml:"serialNumber"`
+	Responder    string     `json:"responder,omitempty" yaml:"responder,omitempty"`
+	Status       string     `json:"status,omitempty" yaml:"status,omitempty"`
+	ThisUpdate   *time.Time `json:"thisUpdate,omitempty" yaml:"thisUpdate,omitempty"`
+	NextUpdate   *time.Time `json:"nextUpdate,omitempty" yaml:"nextUpdate,omitempty"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty" yaml:"revokedAt,omitempty"`
+	Reason       string     `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Signer       string     `json:"signer,omitempty" yaml:"signer,omitempty"`
+	Verified     bool       `json:"verified" yaml:"verified"`
+	Error        string     `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// CheckOCSP requests the OCSP status of certificate from the responder url,
+// or the OCSP server of AIA extension if url is empty, and verifies the
+// response is signed by the issuer or a delegated responder certificate
+// issued by it. The zero at is now.
+func CheckOCSP(cert, issuer *x509.Certificate, url string, at time.Time) *OCSPCheck {
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	check := &OCSPCheck{Subject: cert.Subject.String(), SerialNumber: formatSerial(cert.SerialNumber), Responder: url}
+	if check.Responder == "" {
+		for _, server := range cert.OCSPServer {
+			if strings.HasPrefix(server, "http://") || strings.HasPrefix(server, "https://") {
+				check.Responder = server
+				break
+			}
+		}
+	}
+	if check.Responder == "" {
+		check.Error = fmt.Sprintf("the certificate %s has no OCSP responder URL", check.Subject)
+		return check
+	}
+
+	req, err := ocsp.CreateRequest(cert, issuer, &ocsp.RequestOptions{Hash: crypto.SHA1})
+	if err != nil {
+		check.Error = fmt.Sprintf("Failed to create OCSP request: %v", err)
+		return check
+	}
+
+	data, err := postURL(check.Responder, "application/ocsp-request", req)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	// the signature is verified below, as the responder may embed the issuer
+	// certificate which is not signed by itself
+	resp, err := ocsp.ParseResponseForCert(data, cert, nil)
+	if err != nil {
+		check.Error = fmt.Sprintf("Failed to parse OCSP response from %s: %v", check.Responder, err)
+		return check
+	}
+
+	checkOCSPResponse(check, resp, issuer, at)
+
+	return check
+}
+
+// checkOCSPResponse checks the signature, status and freshness of response
+func checkOCSPResponse(check *OCSPCheck, resp *ocsp.Response, issuer *x509.Certificate, at time.Time) {
+	check.ThisUpdate = &resp.ThisUpdate
+	if !resp.NextUpdate.IsZero() {
+		check.NextUpdate = &resp.NextUpdate
+	}
+
+	if err := verifyOCSPSigner(resp, issuer, at); err != nil {
+		check.Error = err.Error()
+		return
+	}
+	if resp.Certificate != nil && !bytes.Equal(resp.Certificate.Raw, issuer.Raw) {
+		check.Signer = resp.Certificate.Subject.String()
+	}
+
+	var errs []string
+	switch resp.Status {
+	case ocsp.Good:
+		check.Status = OCSPStatusGood
+	case ocsp.Revoked:
+		check.Status = OCSPStatusRevoked
+		check.RevokedAt = &resp.RevokedAt
+		check.Reason = crlReasonToString[resp.RevocationReason]
+		msg := fmt.Sprintf("the certificate %s is revoked at %s", check.Subject, resp.RevokedAt.UTC().Format(time.RFC3339))
+		if check.Reason != "" {
+			msg += ", reason " + check.Reason
+		}
+		errs = append(errs, msg)
+	default:
+		check.Status = OCSPStatusUnknown
+		errs = append(errs, fmt.Sprintf("the OCSP responder %s does not know the certificate %s", check.Responder, check.Subject))
+	}
+
+	if at.Before(resp.ThisUpdate) {
+		errs = append(errs, fmt.Sprintf("the OCSP response is not yet valid, this update is %s", resp.ThisUpdate.UTC().Format(time.RFC3339)))
+	}
+	if !resp.NextUpdate.IsZero() && at.After(resp.NextUpdate) {
+		errs = append(errs, fmt.Sprintf("the OCSP response is stale, next update was %s", resp.NextUpdate.UTC().Format(time.RFC3339)))
+	}
+
+	check.Error = strings.Join(errs, ", ")
+	check.Verified = len(errs) == 0
+}
+
+// verifyOCSPSigner verifies the response is signed by the issuer, or by the
+// delegated responder certificate which is issued by the issuer and has the
+// OCSP signing extended key usage, RFC 6960 section 4.2.2.2
+func verifyOCSPSigner(resp *ocsp.Response, issuer *x509.Certificate, at time.Time) error {
+	if resp.Certificate == nil || bytes.Equal(resp.Certificate.Raw, issuer.Raw) {
+		if err := resp.CheckSignatureFrom(issuer); err != nil {
+			return fmt.Errorf("the OCSP response is not signed by %s: %w", issuer.Subject.String(), err)
+		}
+		return nil
+	}
+
+	signer := resp.Certificate
+	if err := signer.CheckSignatureFrom(issuer); err != nil {
+		return fmt.Errorf("the OCSP responder certificate %s is not issued by %s: %w", signer.Subject.String(), issuer.Subject.String(), err)
+	}
+
+	hasEKU := false
+	for _, eku := range signer.ExtKeyUsage {
+		if eku == x509.ExtKeyUsageOCSPSigning {
+			hasEKU = true
+			break
+		}
+	}
+	if !hasEKU {
+		return fmt.Errorf("the OCSP responder certificate %s does not have the OCSP signing extended key usage", signer.Subject.String())
+	}
+
+	if at.Before(signer.NotBefore) || at.After(signer.NotAfter) {
+		return fmt.Errorf("the OCSP responder certificate %s is expired or not yet valid", signer.Subject.String())
+	}
+
+	// the response signature is verified by ParseResponseForCert
+	return nil
+}
+
+// GetOCSPInfo returns the human readable info of OCSP status
+func GetOCSPInfo(check *OCSPCheck) []map[string]string {
+	var result []map[string]string
+
+	result = append(result, map[string]string{
+		"Subject": check.Subject,
+	})
+	result = append(result, map[string]string{
+		"Serial Number": check.SerialNumber,
+	})
+	if check.Responder != "" {
+		result = append(result, map[string]string{
+			"OCSP Responder": check.Responder,
+		})
+	}
+	if check.Signer != "" {
+		result = append(result, map[string]string{
+			"Signed By": check.Signer,
+		})
+	}
+	if check.Status != "" {
+		result = append(result, map[string]string{
+			"OCSP Status": check.Status,
+		})
+	}
+	if check.RevokedAt != nil {
+		result = append(result, map[string]string{
+			"Revoked At": check.RevokedAt.String(),
+		})
+	}
+	if check.Reason != "" {
+		result = append(result, map[string]string{
+			"Revocation Reason": check.Reason,
+		})
+	}
+	if check.ThisUpdate != nil {
+		result = append(result, map[string]string{
+			"This Update": check.ThisUpdate.String(),
+		})
+	}
+	if check.NextUpdate != nil {
+		result = append(result, map[string]string{
+			"Next Update": check.NextUpdate.String(),
+		})
+	}
+	if check.Error != "" {
+		result = append(result, map[string]string{
+			"Error": check.Error,
+		})
+	}
+
+	return result
+}
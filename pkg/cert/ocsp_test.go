@@ -0,0 +1,96 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestCheckOCSP(t *testing.T) {
+	ca, caKey := newTestCert(t, nil, nil, testCertOptions{subject: "CN=Root CA", isCA: true})
+	leaf, _ := newTestCert(t, ca, caKey, testCertOptions{subject: "CN=leaf.com"})
+	now := time.Now()
+	revokedAt := now.Add(-10 * time.Minute).Truncate(time.Second)
+
+	delegated, delegatedKey := newTestCert(t, ca, caKey, testCertOptions{subject: "CN=OCSP Responder", extUsage: "OCSPSigning"})
+	noEKU, noEKUKey := newTestCert(t, ca, caKey, testCertOptions{subject: "CN=OCSP Responder"})
+	otherCA, otherKey := newTestCert(t, nil, nil, testCertOptions{subject: "CN=Other CA", isCA: true})
+	foreign, foreignKey := newTestCert(t, otherCA, otherKey, testCertOptions{subject: "CN=OCSP Responder", extUsage: "OCSPSigning"})
+
+	var tests = []struct {
+		name      string
+		status    int
+		signer    *x509.Certificate
+		signerKey crypto.Signer
+		embed     bool
+		at        time.Time
+		expect    string
+		delegated bool
+		errMsg    string
+	}{
+		{name: "good", status: ocsp.Good, expect: OCSPStatusGood},
+		{name: "revoked", status: ocsp.Revoked, expect: OCSPStatusRevoked, errMsg: "revoked at " + revokedAt.UTC().Format(time.RFC3339) + ", reason keyCompromise"},
+		{name: "unknown", status: ocsp.Unknown, expect: OCSPStatusUnknown, errMsg: "does not know the certificate"},
+		{name: "embedded issuer", status: ocsp.Good, embed: true, expect: OCSPStatusGood},
+		{name: "delegated", status: ocsp.Good, signer: delegated, signerKey: delegatedKey, embed: true, expect: OCSPStatusGood, delegated: true},
+		{name: "delegated without EKU", status: ocsp.Good, signer: noEKU, signerKey: noEKUKey, embed: true, errMsg: "does not have the OCSP signing extended key usage"},
+		{name: "delegated by other CA", status: ocsp.Good, signer: foreign, signerKey: foreignKey, embed: true, errMsg: "is not issued by CN=Root CA"},
+		{name: "signed by other CA", status: ocsp.Good, signer: otherCA, signerKey: otherKey, errMsg: "is not signed by CN=Root CA"},
+		{name: "stale", status: ocsp.Good, at: now.Add(48 * time.Hour), expect: OCSPStatusGood, errMsg: "is stale"},
+	}
+
+	var respBytes []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if _, err := ocsp.ParseRequest(body); err != nil || r.Header.Get("Content-Type") != "application/ocsp-request" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write(respBytes)
+	}))
+	defer server.Close()
+
+	for _, test := range tests {
+		signer, signerKey := ca, crypto.Signer(caKey)
+		if test.signer != nil {
+			signer, signerKey = test.signer, test.signerKey
+		}
+
+		template := ocsp.Response{
+			Status:           test.status,
+			SerialNumber:     leaf.SerialNumber,
+			ThisUpdate:       now.Add(-time.Minute),
+			NextUpdate:       now.Add(time.Hour),
+			RevokedAt:        revokedAt,
+			RevocationReason: ocsp.KeyCompromise,
+		}
+		if test.embed {
+			template.Certificate = signer
+		}
+		var err error
+		respBytes, err = ocsp.CreateResponse(ca, signer, template, signerKey)
+		if err != nil {
+			t.Fatalf("failed CreateResponse %s: %v", test.name, err)
+		}
+
+		check := CheckOCSP(leaf, ca, server.URL, test.at)
+		if check.Status != test.expect || check.Verified != (test.errMsg == "") || !strings.Contains(check.Error, test.errMsg) {
+			t.Errorf("failed CheckOCSP %s:\n\tactual: %s %s\n\texpect: %s %s\n", test.name, check.Status, check.Error, test.expect, test.errMsg)
+		}
+		if (check.Signer != "") != test.delegated {
+			t.Errorf("failed CheckOCSP %s signer: %q", test.name, check.Signer)
+		}
+	}
+
+	check := CheckOCSP(leaf, ca, "", time.Time{})
+	if check.Verified || !strings.Contains(check.Error, "has no OCSP responder URL") {
+		t.Errorf("failed CheckOCSP without responder: %+v", check)
+	}
+}
@@ -0,0 +1,829 @@
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"unicode/utf16"
+)
+
+var (
+	oidDataContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedDataContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+
+	oidKeyBag              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 1}
+	oidPKCS8ShroudedKeyBag = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidCertTypeX509        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+
+	oidFriendlyName = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidLocalKeyID   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+
+	oidPBEWithSHAAnd3KeyTripleDESCBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidPBEWithSHAAnd128BitRC2CBC     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 5}
+	oidPBEWithSHAAnd40BitRC2CBC      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 6}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+const pkcs12MacIterations = 2048
+
+var ErrIncorrectPassword = errors.New("PKCS#12 MAC verification failed, incorrect password")
+
+// PKCS12 is the content of a PKCS#12(PFX) file
+type PKCS12 struct {
+	FriendlyName string
+	// Key is nil if there is no private key
+	Key interface{}
+	// Cert is the certificate of Key, nil if there is no private key
+	Cert *x509.Certificate
+	// CACerts are all the other certificates
+	CACerts []*x509.Certificate
+}
+
+// RFC 7292 section 4
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"tag:0,optional"`
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue     `asn1:"tag:0,explicit"`
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type certBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+// RFC 7292 appendix C
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+// EncodePKCS12 encodes the private key and certificates to PKCS#12, the private
+// key and certificates are encrypted with PBES2 AES-256-CBC and the integrity
+// is protected with HMAC-SHA256. The key can be nil for a certificates only file.
+func EncodePKCS12(key crypto.PrivateKey, cert *x509.Certificate, caCerts []*x509.Certificate, password, friendlyName string) ([]byte, error) {
+	var certBags []safeBag
+	var keyBags []safeBag
+
+	if cert != nil {
+		var attrs []pkcs12Attribute
+		if key != nil {
+			localKeyID := sha1.Sum(cert.Raw)
+			attr, err := newLocalKeyIDAttribute(localKeyID[:])
+			if err != nil {
+				return nil, err
+			}
+			attrs = append(attrs, attr)
+
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			if err != nil {
+				return nil, err
+			}
+			algo, encrypted, err := pbes2Encrypt(der, []byte(password), KDFPBKDF2)
+			if err != nil {
+				return nil, err
+			}
+
+			bag, err := newSafeBag(oidPKCS8ShroudedKeyBag, encryptedPrivateKeyInfo{Algo: algo, EncryptedData: encrypted}, attrs, friendlyName)
+			if err != nil {
+				return nil, err
+			}
+			keyBags = append(keyBags, bag)
+		}
+
+		bag, err := newSafeBag(oidCertBag, certBag{ID: oidCertTypeX509, Data: cert.Raw}, attrs, friendlyName)
+		if err != nil {
+			return nil, err
+		}
+		certBags = append(certBags, bag)
+	} else if key != nil {
+		return nil, fmt.Errorf("No certificate for the private key")
+	}
+
+	for _, caCert := range caCerts {
+		bag, err := newSafeBag(oidCertBag, certBag{ID: oidCertTypeX509, Data: caCert.Raw}, nil, "")
+		if err != nil {
+			return nil, err
+		}
+		certBags = append(certBags, bag)
+	}
+
+	var authSafe []contentInfo
+
+	// the certificates are encrypted
+	certContents, err := asn1.Marshal(certBags)
+	if err != nil {
+		return nil, err
+	}
+	algo, encrypted, err := pbes2Encrypt(certContents, []byte(password), KDFPBKDF2)
+	if err != nil {
+		return nil, err
+	}
+	encryptedCerts, err := asn1.Marshal(encryptedData{
+		Version: 0,
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType:                oidDataContentType,
+			ContentEncryptionAlgorithm: algo,
+			EncryptedContent:           asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: encrypted},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	authSafe = append(authSafe, contentInfo{
+		ContentType: oidEncryptedDataContentType,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: encryptedCerts},
+	})
+
+	// the private key is already encrypted in the shrouded key bag
+	if len(keyBags) > 0 {
+		keyContents, err := asn1.Marshal(keyBags)
+		if err != nil {
+			return nil, err
+		}
+		ci, err := newDataContentInfo(keyContents)
+		if err != nil {
+			return nil, err
+		}
+		authSafe = append(authSafe, ci)
+	}
+
+	authSafeBytes, err := asn1.Marshal(authSafe)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	pfx := pfxPdu{Version: 3}
+	pfx.AuthSafe, err = newDataContentInfo(authSafeBytes)
+	if err != nil {
+		return nil, err
+	}
+	pfx.MacData = macData{
+		Mac: digestInfo{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			Digest:    pkcs12MAC(sha256.New, authSafeBytes, salt, pkcs12MacIterations, bmpPassword(password)),
+		},
+		MacSalt:    salt,
+		Iterations: pkcs12MacIterations,
+	}
+
+	return asn1.Marshal(pfx)
+}
+
+// DecodePKCS12 decodes the PKCS#12 file, both the modern PBES2 and the legacy
+// 3DES and RC2 encryption are supported
+func DecodePKCS12(pfxData []byte, password string) (*PKCS12, error) {
+	pfxData, err := berToDER(pfxData)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse PKCS#12: %w", err)
+	}
+
+	var pfx pfxPdu
+	if rest, err := asn1.Unmarshal(pfxData, &pfx); err != nil {
+		return nil, fmt.Errorf("Failed to parse PKCS#12: %w", err)
+	} else if len(rest) > 0 {
+		return nil, fmt.Errorf("Failed to parse PKCS#12: trailing data")
+	}
+
+	if pfx.Version != 3 {
+		return nil, fmt.Errorf("Unsupported PKCS#12 version: %d", pfx.Version)
+	}
+
+	if !pfx.AuthSafe.ContentType.Equal(oidDataContentType) {
+		return nil, fmt.Errorf("Unsupported PKCS#12 content type: %s", pfx.AuthSafe.ContentType)
+	}
+
+	var authSafeBytes []byte
+	if _, err := asn1.Unmarshal(pfx.AuthSafe.Content.Bytes, &authSafeBytes); err != nil {
+		return nil, fmt.Errorf("Failed to parse PKCS#12 content: %w", err)
+	}
+
+	if len(pfx.MacData.Mac.Algorithm.Algorithm) > 0 {
+		if err := verifyPKCS12MAC(&pfx.MacData, authSafeBytes, password); err != nil {
+			return nil, err
+		}
+	}
+
+	// the MAC is over the content octets, the content itself may be BER too
+	authSafeBytes, err = berToDER(authSafeBytes)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse PKCS#12 content: %w", err)
+	}
+
+	var authSafe []contentInfo
+	if _, err := asn1.Unmarshal(authSafeBytes, &authSafe); err != nil {
+		return nil, fmt.Errorf("Failed to parse PKCS#12 content: %w", err)
+	}
+
+	var bags []safeBag
+	for _, ci := range authSafe {
+		var data []byte
+		switch {
+		case ci.ContentType.Equal(oidDataContentType):
+			if _, err := asn1.Unmarshal(ci.Content.Bytes, &data); err != nil {
+				return nil, fmt.Errorf("Failed to parse PKCS#12 content: %w", err)
+			}
+		case ci.ContentType.Equal(oidEncryptedDataContentType):
+			var ed encryptedData
+			if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+				return nil, fmt.Errorf("Failed to parse PKCS#12 encrypted content: %w", err)
+			}
+			data, err = pbeDecrypt(ed.EncryptedContentInfo.ContentEncryptionAlgorithm, getEncryptedContent(ed.EncryptedContentInfo.EncryptedContent), []byte(password))
+			if err != nil {
+				return nil, fmt.Errorf("Failed to decrypt PKCS#12 content: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("Unsupported PKCS#12 content type: %s", ci.ContentType)
+		}
+
+		data, err = berToDER(data)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse PKCS#12 safe contents: %w", err)
+		}
+
+		var safeContents []safeBag
+		if _, err := asn1.Unmarshal(data, &safeContents); err != nil {
+			return nil, fmt.Errorf("Failed to parse PKCS#12 safe contents: %w", err)
+		}
+		bags = append(bags, safeContents...)
+	}
+
+	return decodeSafeBags(bags, password)
+}
+
+// IsPKCS12 reports whether the data looks like a PKCS#12 file
+func IsPKCS12(data []byte) bool {
+	data, err := berToDER(data)
+	if err != nil {
+		return false
+	}
+
+	var pfx pfxPdu
+	if _, err := asn1.Unmarshal(data, &pfx); err != nil {
+		return false
+	}
+
+	return pfx.Version == 3 && pfx.AuthSafe.ContentType.Equal(oidDataContentType)
+}
+
+func decodeSafeBags(bags []safeBag, password string) (*PKCS12, error) {
+	p12 := &PKCS12{}
+
+	var keyID []byte
+	var certs []*x509.Certificate
+	var certKeyIDs [][]byte
+
+	for _, bag := range bags {
+		localKeyID, friendlyName := getBagAttributes(bag.Attributes)
+
+		switch {
+		case bag.ID.Equal(oidKeyBag), bag.ID.Equal(oidPKCS8ShroudedKeyBag):
+			if p12.Key != nil {
+				return nil, fmt.Errorf("Unsupported PKCS#12 with multiple private keys")
+			}
+
+			der := bag.Value.Bytes
+			if bag.ID.Equal(oidPKCS8ShroudedKeyBag) {
+				var err error
+				der, err = DecryptPKCS8PrivateKey(bag.Value.Bytes, []byte(password))
+				if err != nil {
+					return nil, fmt.Errorf("Failed to decrypt PKCS#12 private key: %w", err)
+				}
+			}
+
+			key, err := x509.ParsePKCS8PrivateKey(der)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to parse PKCS#12 private key: %w", err)
+			}
+			p12.Key = key
+			keyID = localKeyID
+			if friendlyName != "" {
+				p12.FriendlyName = friendlyName
+			}
+		case bag.ID.Equal(oidCertBag):
+			var cb certBag
+			if _, err := asn1.Unmarshal(bag.Value.Bytes, &cb); err != nil {
+				return nil, fmt.Errorf("Failed to parse PKCS#12 certificate bag: %w", err)
+			}
+			if !cb.ID.Equal(oidCertTypeX509) {
+				// ignore SDSI certificates
+				continue
+			}
+
+			cert, err := x509.ParseCertificate(cb.Data)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to parse PKCS#12 certificate: %w", err)
+			}
+			certs = append(certs, cert)
+			certKeyIDs = append(certKeyIDs, localKeyID)
+			if friendlyName != "" && p12.FriendlyName == "" {
+				p12.FriendlyName = friendlyName
+			}
+		}
+	}
+
+	// find the certificate of private key by local key ID or public key
+	leaf := -1
+	if p12.Key != nil {
+		for i := range certs {
+			if len(keyID) > 0 && bytes.Equal(keyID, certKeyIDs[i]) {
+				leaf = i
+				break
+			}
+		}
+
+		if leaf < 0 {
+			if signer, ok := p12.Key.(crypto.Signer); ok {
+				for i, cert := range certs {
+					if PublicKeyEqual(cert.PublicKey, signer.Public()) {
+						leaf = i
+						break
+					}
+				}
+			}
+		}
+	}
+
+	for i, cert := range certs {
+		if i == leaf {
+			p12.Cert = cert
+		} else {
+			p12.CACerts = append(p12.CACerts, cert)
+		}
+	}
+
+	return p12, nil
+}
+
+func getBagAttributes(attrs []pkcs12Attribute) ([]byte, string) {
+	var localKeyID []byte
+	var friendlyName string
+
+	for _, attr := range attrs {
+		switch {
+		case attr.ID.Equal(oidLocalKeyID):
+			var id []byte
+			if _, err := asn1.Unmarshal(attr.Value.Bytes, &id); err == nil {
+				localKeyID = id
+			}
+		case attr.ID.Equal(oidFriendlyName):
+			var raw asn1.RawValue
+			if _, err := asn1.Unmarshal(attr.Value.Bytes, &raw); err == nil && raw.Tag == asn1.TagBMPString {
+				friendlyName = decodeBMPString(raw.Bytes)
+			}
+		}
+	}
+
+	return localKeyID, friendlyName
+}
+
+func newSafeBag(id asn1.ObjectIdentifier, value interface{}, attrs []pkcs12Attribute, friendlyName string) (safeBag, error) {
+	bytes, err := asn1.Marshal(value)
+	if err != nil {
+		return safeBag{}, err
+	}
+
+	if friendlyName != "" {
+		attr, err := newFriendlyNameAttribute(friendlyName)
+		if err != nil {
+			return safeBag{}, err
+		}
+		attrs = append([]pkcs12Attribute{attr}, attrs...)
+	}
+
+	return safeBag{
+		ID:         id,
+		Value:      asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: bytes},
+		Attributes: attrs,
+	}, nil
+}
+
+func newLocalKeyIDAttribute(id []byte) (pkcs12Attribute, error) {
+	value, err := asn1.Marshal(id)
+	if err != nil {
+		return pkcs12Attribute{}, err
+	}
+
+	return pkcs12Attribute{
+		ID:    oidLocalKeyID,
+		Value: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+	}, nil
+}
+
+func newFriendlyNameAttribute(name string) (pkcs12Attribute, error) {
+	value, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagBMPString, Bytes: encodeBMPString(name)})
+	if err != nil {
+		return pkcs12Attribute{}, err
+	}
+
+	return pkcs12Attribute{
+		ID:    oidFriendlyName,
+		Value: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+	}, nil
+}
+
+func newDataContentInfo(data []byte) (contentInfo, error) {
+	bytes, err := asn1.Marshal(data)
+	if err != nil {
+		return contentInfo{}, err
+	}
+
+	return contentInfo{
+		ContentType: oidDataContentType,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: bytes},
+	}, nil
+}
+
+// the encrypted content can be a constructed octet string in the files exported by Windows
+func getEncryptedContent(raw asn1.RawValue) []byte {
+	if !raw.IsCompound {
+		return raw.Bytes
+	}
+
+	var content []byte
+	rest := raw.Bytes
+	for len(rest) > 0 {
+		var part []byte
+		var err error
+		rest, err = asn1.Unmarshal(rest, &part)
+		if err != nil {
+			return nil
+		}
+		content = append(content, part...)
+	}
+
+	return content
+}
+
+func verifyPKCS12MAC(md *macData, content []byte, password string) error {
+	var newHash func() hash.Hash
+	switch {
+	case md.Mac.Algorithm.Algorithm.Equal(oidSHA1):
+		newHash = sha1.New
+	case md.Mac.Algorithm.Algorithm.Equal(oidSHA256):
+		newHash = sha256.New
+	case md.Mac.Algorithm.Algorithm.Equal(oidSHA384):
+		newHash = sha512.New384
+	case md.Mac.Algorithm.Algorithm.Equal(oidSHA512):
+		newHash = sha512.New
+	default:
+		return fmt.Errorf("Unsupported PKCS#12 MAC algorithm: %s", md.Mac.Algorithm.Algorithm)
+	}
+
+	if err := checkPKCS12Iterations(md.Iterations); err != nil {
+		return err
+	}
+
+	mac := pkcs12MAC(newHash, content, md.MacSalt, md.Iterations, bmpPassword(password))
+	if hmac.Equal(mac, md.Mac.Digest) {
+		return nil
+	}
+
+	// some implementations use the empty BMPString without the trailing zeros for empty password
+	if password == "" {
+		mac = pkcs12MAC(newHash, content, md.MacSalt, md.Iterations, nil)
+		if hmac.Equal(mac, md.Mac.Digest) {
+			return nil
+		}
+	}
+
+	return ErrIncorrectPassword
+}
+
+// checkPKCS12Iterations checks the iteration count read from PKCS#12 file is
+// within the limit of PBKDF2 iterations
+func checkPKCS12Iterations(iterations int) error {
+	if iterations < 1 || iterations > maxPBKDF2Iterations {
+		return fmt.Errorf("Invalid PKCS#12 iteration count: %d, must be between 1 and %d", iterations, maxPBKDF2Iterations)
+	}
+
+	return nil
+}
+
+func pkcs12MAC(newHash func() hash.Hash, content, salt []byte, iterations int, password []byte) []byte {
+	key := pkcs12KDF(newHash, salt, password, iterations, 3, newHash().Size())
+	mac := hmac.New(newHash, key)
+	mac.Write(content)
+	return mac.Sum(nil)
+}
+
+// pkcs12PBEDecrypt decrypts data with the legacy PKCS#12 password based
+// encryption, see RFC 7292 appendix B and C
+func pkcs12PBEDecrypt(algo pkix.AlgorithmIdentifier, data, password []byte) ([]byte, error) {
+	var params pbeParams
+	if _, err := asn1.Unmarshal(algo.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("Failed to parse PBE parameters: %w", err)
+	}
+
+	var keyLen int
+	var newCipher func([]byte) (cipher.Block, error)
+	switch {
+	case algo.Algorithm.Equal(oidPBEWithSHAAnd3KeyTripleDESCBC):
+		keyLen, newCipher = 24, des.NewTripleDESCipher
+	case algo.Algorithm.Equal(oidPBEWithSHAAnd128BitRC2CBC):
+		keyLen = 16
+		newCipher = func(key []byte) (cipher.Block, error) { return newRC2Cipher(key, 128) }
+	case algo.Algorithm.Equal(oidPBEWithSHAAnd40BitRC2CBC):
+		keyLen = 5
+		newCipher = func(key []byte) (cipher.Block, error) { return newRC2Cipher(key, 40) }
+	default:
+		return nil, fmt.Errorf("Unsupported encryption algorithm: %s", algo.Algorithm)
+	}
+
+	if err := checkPKCS12Iterations(params.Iterations); err != nil {
+		return nil, err
+	}
+
+	bmp := bmpPassword(string(password))
+	key := pkcs12KDF(sha1.New, params.Salt, bmp, params.Iterations, 1, keyLen)
+	iv := pkcs12KDF(sha1.New, params.Salt, bmp, params.Iterations, 2, 8)
+
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cbcDecrypt(block, iv, data)
+}
+
+// pkcs12KDF derives key from password, see RFC 7292 appendix B.2
+func pkcs12KDF(newHash func() hash.Hash, salt, password []byte, iterations int, id byte, size int) []byte {
+	h := newHash()
+	u := h.Size()
+	v := h.BlockSize()
+
+	d := make([]byte, v)
+	for i := range d {
+		d[i] = id
+	}
+
+	fill := func(b []byte) []byte {
+		if len(b) == 0 {
+			return nil
+		}
+		out := make([]byte, v*((len(b)+v-1)/v))
+		for i := range out {
+			out[i] = b[i%len(b)]
+		}
+		return out
+	}
+	in := append(fill(salt), fill(password)...)
+
+	var result []byte
+	for len(result) < size {
+		h.Reset()
+		h.Write(d)
+		h.Write(in)
+		a := h.Sum(nil)
+		for n := 1; n < iterations; n++ {
+			h.Reset()
+			h.Write(a)
+			a = h.Sum(a[:0])
+		}
+		result = append(result, a...)
+
+		if len(result) >= size {
+			break
+		}
+
+		b := make([]byte, v)
+		for n := range b {
+			b[n] = a[n%u]
+		}
+
+		// I_j = (I_j + B + 1) mod 2^v
+		for j := 0; j < len(in); j += v {
+			carry := 1
+			for n := v - 1; n >= 0; n-- {
+				sum := int(in[j+n]) + int(b[n]) + carry
+				in[j+n] = byte(sum)
+				carry = sum >> 8
+			}
+		}
+	}
+
+	return result[:size]
+}
+
+// bmpPassword encodes the password to BMPString with the trailing zeros
+func bmpPassword(password string) []byte {
+	return append(encodeBMPString(password), 0, 0)
+}
+
+func encodeBMPString(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, 0, 2*len(u))
+	for _, c := range u {
+		b = append(b, byte(c>>8), byte(c))
+	}
+	return b
+}
+
+func decodeBMPString(b []byte) string {
+	if len(b)%2 != 0 {
+		return ""
+	}
+
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+
+	// remove the trailing zeros
+	if len(u) > 0 && u[len(u)-1] == 0 {
+		u = u[:len(u)-1]
+	}
+
+	return string(utf16.Decode(u))
+}
+
+// berToDER converts the indefinite length BER encoding to DER, some PKCS#12
+// files are BER encoded
+func berToDER(ber []byte) ([]byte, error) {
+	out, rest, err := berConvert(ber)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("trailing data after BER element")
+	}
+	return out, nil
+}
+
+func berConvert(ber []byte) ([]byte, []byte, error) {
+	if len(ber) < 2 {
+		return nil, nil, fmt.Errorf("BER element too short")
+	}
+
+	// tag, only the low tag number form is used in PKCS#12
+	tag := ber[0]
+	if tag&0x1f == 0x1f {
+		return nil, nil, fmt.Errorf("unsupported BER high tag number")
+	}
+	compound := tag&0x20 != 0
+	offset := 1
+
+	var contents []byte
+	var rest []byte
+	if ber[offset] == 0x80 {
+		// indefinite length, only for constructed encoding
+		if !compound {
+			return nil, nil, fmt.Errorf("indefinite length for primitive BER element")
+		}
+		offset++
+		rest = ber[offset:]
+		for {
+			if len(rest) < 2 {
+				return nil, nil, fmt.Errorf("missing BER end-of-contents")
+			}
+			if rest[0] == 0 && rest[1] == 0 {
+				rest = rest[2:]
+				break
+			}
+			var child []byte
+			var err error
+			child, rest, err = berConvert(rest)
+			if err != nil {
+				return nil, nil, err
+			}
+			contents = append(contents, child...)
+		}
+	} else {
+		length := int(ber[offset])
+		offset++
+		if length&0x80 != 0 {
+			n := length & 0x7f
+			if n > 4 || offset+n > len(ber) {
+				return nil, nil, fmt.Errorf("invalid BER length")
+			}
+			length = 0
+			for _, b := range ber[offset : offset+n] {
+				length = length<<8 | int(b)
+			}
+			offset += n
+		}
+		if length < 0 || offset+length > len(ber) {
+			return nil, nil, fmt.Errorf("BER element exceeds data")
+		}
+
+		rest = ber[offset+length:]
+		body := ber[offset : offset+length]
+		if compound {
+			for len(body) > 0 {
+				var child []byte
+				var err error
+				child, body, err = berConvert(body)
+				if err != nil {
+					return nil, nil, err
+				}
+				contents = append(contents, child...)
+			}
+		} else {
+			contents = body
+		}
+	}
+
+	// the segments of constructed OCTET STRING are concatenated into one
+	// primitive OCTET STRING, as DER requires
+	if tag == 0x24 {
+		var err error
+		if contents, err = flattenOctetString(contents); err != nil {
+			return nil, nil, err
+		}
+		tag = 0x04
+	}
+
+	return append(appendDERHeader(nil, tag, len(contents)), contents...), rest, nil
+}
+
+// flattenOctetString concatenates the DER OCTET STRING segments
+func flattenOctetString(segments []byte) ([]byte, error) {
+	var contents []byte
+	for len(segments) > 0 {
+		var segment asn1.RawValue
+		var err error
+		segments, err = asn1.Unmarshal(segments, &segment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BER OCTET STRING segment: %w", err)
+		}
+		if segment.Class != asn1.ClassUniversal || segment.Tag != asn1.TagOctetString || segment.IsCompound {
+			return nil, fmt.Errorf("invalid BER OCTET STRING segment with tag %d", segment.Tag)
+		}
+		contents = append(contents, segment.Bytes...)
+	}
+
+	return contents, nil
+}
+
+func appendDERHeader(b []byte, tag byte, length int) []byte {
+	b = append(b, tag)
+	if length < 0x80 {
+		return append(b, byte(length))
+	}
+
+	var lb []byte
+	for l := length; l > 0; l >>= 8 {
+		lb = append([]byte{byte(l)}, lb...)
+	}
+	b = append(b, 0x80|byte(len(lb)))
+	return append(b, lb...)
+}
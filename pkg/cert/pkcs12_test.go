@@ -0,0 +1,289 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// generated by: openssl pkcs12 -export -legacy -name legacy -passout pass:secret,
+// the certificates are encrypted with RC2-40 and the private key with 3DES
+var legacyPKCS12 = `
+	MIIDKwIBAzCCAvEGCSqGSIb3DQEHAaCCAuIEggLeMIIC2jCCAg8GCSqGSIb3DQEHBqCCAgAwggH8
+	AgEAMIIB9QYJKoZIhvcNAQcBMBwGCiqGSIb3DQEMAQYwDgQIlkXHBjVrc+oCAggAgIIByNONb9sW
+	uiBd0A46AfXQo+SuKeamjGpJW5T0Va6JRwbucvOf+SyxkyRK6/XS+B39Ix/wpk5XMUe04KAAhDDX
+	3Qto3zXEiOn2i+fBOQHqTgsHRUe92PM2Zq5CRvclETBhmEObNHKwkO7swvlHT9Y8bNgS2KdBggJq
+	kBaoJcP4t/oDDVGY4Ojg5IzeOI6hCRUqSMHpCzLG2WO2224nJKp1gXg3y/cUmRMKBG5XZCdY7W+W
+	0HdfnWizn+A5k4MTR/b6vLt6ZEef8WvIf1TPV1I4QPPBpN796fG11PUUfw84WPhGtp5b1W/Qwmsx
+	V/NT+1phbHFYCfkIMpH0ALEcaZBIIDp+bPOAtKHHBKHW/crUBqxTEjL8wqI/8n8iNf3evl7y+Vvr
+	BIi/So39ua/e2cWcJk+A0d5+0lJqV66WUGG4rCBYkoLZ1D5A+oo9/wwEwoFNhrnaXYzf/9z7g4ot
+	oXKnsrChlwitdq5a40XXd5hY9acGDIwti3fKfojwQuNW9HxGk0HE5GHv76Gbh3MH1O9DvHA07hQC
+	AvmkL/Q3I46CHA5qdUP2DiN0yDohZhDIRkondmAl5jGF2N5uzRXOOFCQBkqzq84IOCGuFTCBxAYJ
+	KoZIhvcNAQcBoIG2BIGzMIGwMIGtBgsqhkiG9w0BDAoBAqBaMFgwHAYKKoZIhvcNAQwBAzAOBAhS
+	Weyxf8eVIwICCAAEOLGLk8ddoJ7qWniVJaL5vlBLRV8iO6RnMMdhxuEFFtHxIQdRPKB+gp6BKt1O
+	XEDPL6Nq2gNFk1t1MUIwGwYJKoZIhvcNAQkUMQ4eDABsAGUAZwBhAGMAeTAjBgkqhkiG9w0BCRUx
+	FgQUKEOpHVlmrK+8zQf+swYW73sQUX8wMTAhMAkGBSsOAwIaBQAEFDQfUzeu7PaqceYCnGEIFsRZ
+	92aTBAjLdQmaZdtkCgICCAA=
+`
+
+func TestRC2Cipher(t *testing.T) {
+	// RFC 2268 section 5
+	var tests = []struct {
+		key           string
+		effectiveBits int
+		plain         string
+		cipher        string
+	}{
+		{key: "0000000000000000", effectiveBits: 63, plain: "0000000000000000", cipher: "ebb773f993278eff"},
+		{key: "ffffffffffffffff", effectiveBits: 64, plain: "ffffffffffffffff", cipher: "278b27e42e2f0d49"},
+		{key: "3000000000000000", effectiveBits: 64, plain: "1000000000000001", cipher: "30649edf9be7d2c2"},
+		{key: "88", effectiveBits: 64, plain: "0000000000000000", cipher: "61a8a244adacccf0"},
+		{key: "88bca90e90875a", effectiveBits: 64, plain: "0000000000000000", cipher: "6ccf4308974c267f"},
+	}
+
+	for _, test := range tests {
+		key, _ := hex.DecodeString(test.key)
+		plain, _ := hex.DecodeString(test.plain)
+
+		block, err := newRC2Cipher(key, test.effectiveBits)
+		if err != nil {
+			t.Fatalf("failed newRC2Cipher: %v", err)
+		}
+
+		encrypted := make([]byte, 8)
+		block.Encrypt(encrypted, plain)
+		if hex.EncodeToString(encrypted) != test.cipher {
+			t.Errorf("failed RC2 Encrypt:\n\tactual: %x\n\texpect: %s\n", encrypted, test.cipher)
+		}
+
+		decrypted := make([]byte, 8)
+		block.Decrypt(decrypted, encrypted)
+		if hex.EncodeToString(decrypted) != test.plain {
+			t.Errorf("failed RC2 Decrypt:\n\tactual: %x\n\texpect: %s\n", decrypted, test.plain)
+		}
+	}
+}
+
+func TestEncodePKCS12(t *testing.T) {
+	caKeyAlg, _ := NewKeyAlgorithm(KeyTypeECDSA, 0, "P-256")
+	caKey, err := caKeyAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+	caInfo, err := NewCertInfo(time.Hour, "CN=root-ca", "", "keyCertSign", "", true)
+	if err != nil {
+		t.Fatalf("failed NewCertInfo: %v", err)
+	}
+	caBytes, err := NewCert(caInfo, caKey)
+	if err != nil {
+		t.Fatalf("failed NewCert: %v", err)
+	}
+	caCert, _ := ParseCert(caBytes)
+
+	keyAlg, _ := NewKeyAlgorithm(KeyTypeRSA, 2048, "")
+	key, err := keyAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+	certInfo, err := NewCertInfo(time.Hour, "CN=china", "china.com", "digitalSignature", "serverAuth", false)
+	if err != nil {
+		t.Fatalf("failed NewCertInfo: %v", err)
+	}
+	certBytes, err := NewSignedCert(caCert, caKey, certInfo, key.Public())
+	if err != nil {
+		t.Fatalf("failed NewSignedCert: %v", err)
+	}
+	cert, _ := ParseCert(certBytes)
+
+	pfxData, err := EncodePKCS12(key, cert, []*x509.Certificate{caCert}, "密码 secret", "china")
+	if err != nil {
+		t.Fatalf("failed EncodePKCS12: %v", err)
+	}
+
+	if !IsPKCS12(pfxData) {
+		t.Errorf("failed IsPKCS12")
+	}
+
+	if _, err := DecodePKCS12(pfxData, "wrong"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("failed DecodePKCS12 with wrong password:\n\tactual: %v\n\texpect: %v\n", err, ErrIncorrectPassword)
+	}
+
+	p12, err := DecodePKCS12(pfxData, "密码 secret")
+	if err != nil {
+		t.Fatalf("failed DecodePKCS12: %v", err)
+	}
+
+	if p12.FriendlyName != "china" {
+		t.Errorf("failed DecodePKCS12.FriendlyName:\n\tactual: %v\n\texpect: %v\n", p12.FriendlyName, "china")
+	}
+	if p12.Cert == nil || !p12.Cert.Equal(cert) {
+		t.Errorf("failed DecodePKCS12.Cert: %v", p12.Cert)
+	}
+	if len(p12.CACerts) != 1 || !p12.CACerts[0].Equal(caCert) {
+		t.Errorf("failed DecodePKCS12.CACerts: %v", p12.CACerts)
+	}
+	if !PublicKeyEqual(key.Public(), p12.Key.(interface{ Public() crypto.PublicKey }).Public()) {
+		t.Errorf("failed DecodePKCS12.Key: public key mismatch")
+	}
+
+	// certificates only
+	pfxData, err = EncodePKCS12(nil, nil, []*x509.Certificate{caCert}, "", "")
+	if err != nil {
+		t.Fatalf("failed EncodePKCS12 without key: %v", err)
+	}
+	p12, err = DecodePKCS12(pfxData, "")
+	if err != nil {
+		t.Fatalf("failed DecodePKCS12 without key: %v", err)
+	}
+	if p12.Key != nil || p12.Cert != nil || len(p12.CACerts) != 1 {
+		t.Errorf("failed DecodePKCS12 without key: %+v", p12)
+	}
+}
+
+func TestDecodeLegacyPKCS12(t *testing.T) {
+	pfxData, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(legacyPKCS12), ""))
+	if err != nil {
+		t.Fatalf("failed to decode base64: %v", err)
+	}
+
+	p12, err := DecodePKCS12(pfxData, "secret")
+	if err != nil {
+		t.Fatalf("failed DecodePKCS12: %v", err)
+	}
+
+	if p12.FriendlyName != "legacy" {
+		t.Errorf("failed DecodePKCS12.FriendlyName:\n\tactual: %v\n\texpect: %v\n", p12.FriendlyName, "legacy")
+	}
+
+	key, ok := p12.Key.(ed25519.PrivateKey)
+	if !ok {
+		t.Fatalf("failed DecodePKCS12.Key: %T", p12.Key)
+	}
+
+	if p12.Cert == nil || !PublicKeyEqual(p12.Cert.PublicKey, key.Public()) {
+		t.Errorf("failed DecodePKCS12.Cert: %v", p12.Cert)
+	}
+}
+
+func TestBERToDER(t *testing.T) {
+	var tests = []struct {
+		name   string
+		ber    []byte
+		expect []byte
+	}{
+		{
+			// SEQUENCE(indefinite) { OCTET STRING "ab", SEQUENCE(indefinite) { INTEGER 1 } }
+			name:   "indefinite",
+			ber:    []byte{0x30, 0x80, 0x04, 0x02, 'a', 'b', 0x30, 0x80, 0x02, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00},
+			expect: []byte{0x30, 0x09, 0x04, 0x02, 'a', 'b', 0x30, 0x03, 0x02, 0x01, 0x01},
+		},
+		{
+			// constructed OCTET STRING(indefinite) { "ab", constructed OCTET STRING { "c" } }
+			name:   "chunked",
+			ber:    []byte{0x24, 0x80, 0x04, 0x02, 'a', 'b', 0x24, 0x03, 0x04, 0x01, 'c', 0x00, 0x00},
+			expect: []byte{0x04, 0x03, 'a', 'b', 'c'},
+		},
+	}
+
+	for _, test := range tests {
+		der, err := berToDER(test.ber)
+		if err != nil {
+			t.Fatalf("failed berToDER %s: %v", test.name, err)
+		}
+		if hex.EncodeToString(der) != hex.EncodeToString(test.expect) {
+			t.Errorf("failed berToDER %s:\n\tactual: %x\n\texpect: %x\n", test.name, der, test.expect)
+		}
+	}
+
+	// a constructed OCTET STRING can only contain OCTET STRINGs
+	if _, err := berToDER([]byte{0x24, 0x80, 0x02, 0x01, 0x01, 0x00, 0x00}); err == nil {
+		t.Errorf("failed berToDER: expect error for INTEGER segment")
+	}
+}
+
+func TestDecodeBERPKCS12(t *testing.T) {
+	cert, key := newTestCert(t, nil, nil, testCertOptions{subject: "CN=china"})
+	pfxData, err := EncodePKCS12(key, cert, nil, "secret", "china")
+	if err != nil {
+		t.Fatalf("failed EncodePKCS12: %v", err)
+	}
+
+	// split parses the DER elements of the constructed value
+	split := func(der []byte) []asn1.RawValue {
+		var outer asn1.RawValue
+		if _, err := asn1.Unmarshal(der, &outer); err != nil {
+			t.Fatalf("failed Unmarshal: %v", err)
+		}
+		var values []asn1.RawValue
+		for rest := outer.Bytes; len(rest) > 0; {
+			var v asn1.RawValue
+			if rest, err = asn1.Unmarshal(rest, &v); err != nil {
+				t.Fatalf("failed Unmarshal: %v", err)
+			}
+			values = append(values, v)
+		}
+		return values
+	}
+	indefinite := func(tag byte, contents ...[]byte) []byte {
+		b := []byte{tag, 0x80}
+		for _, c := range contents {
+			b = append(b, c...)
+		}
+		return append(b, 0x00, 0x00)
+	}
+
+	// PFX { version, ContentInfo { contentType, [0] { OCTET STRING } }, macData }
+	pfx := split(pfxData)
+	authSafe := split(pfx[1].FullBytes)
+	octets := split(authSafe[1].FullBytes)[0].Bytes
+
+	// the authSafe content in 100 bytes segments
+	var segments [][]byte
+	for len(octets) > 0 {
+		n := min(100, len(octets))
+		segments = append(segments, append(appendDERHeader(nil, 0x04, n), octets[:n]...))
+		octets = octets[n:]
+	}
+	ber := indefinite(0x30,
+		pfx[0].FullBytes,
+		indefinite(0x30, authSafe[0].FullBytes, indefinite(0xa0, indefinite(0x24, segments...))),
+		pfx[2].FullBytes,
+	)
+
+	p12, err := DecodePKCS12(ber, "secret")
+	if err != nil {
+		t.Fatalf("failed DecodePKCS12: %v", err)
+	}
+	if p12.Cert == nil || !p12.Cert.Equal(cert) || p12.FriendlyName != "china" {
+		t.Errorf("failed DecodePKCS12: %+v", p12)
+	}
+}
+
+func TestDecodePKCS12Iterations(t *testing.T) {
+	cert, key := newTestCert(t, nil, nil, testCertOptions{subject: "CN=china"})
+	pfxData, err := EncodePKCS12(key, cert, nil, "secret", "china")
+	if err != nil {
+		t.Fatalf("failed EncodePKCS12: %v", err)
+	}
+
+	var pfx pfxPdu
+	if _, err := asn1.Unmarshal(pfxData, &pfx); err != nil {
+		t.Fatalf("failed Unmarshal: %v", err)
+	}
+	pfx.MacData.Iterations = maxPBKDF2Iterations + 1
+	pfxData, err = asn1.Marshal(pfx)
+	if err != nil {
+		t.Fatalf("failed Marshal: %v", err)
+	}
+
+	if _, err := DecodePKCS12(pfxData, "secret"); err == nil || !strings.Contains(err.Error(), "Invalid PKCS#12 iteration count") {
+		t.Errorf("failed DecodePKCS12:\n\tactual: %v\n\texpect: Invalid PKCS#12 iteration count\n", err)
+	}
+}
@@ -0,0 +1,101 @@
+package cert
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+)
+
+const PKCS7BlockType = "PKCS7"
+
+var oidSignedDataContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+// RFC 2315 section 9.1, only the certificates are used
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      contentInfo
+	Certificates     asn1.RawValue   `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue   `asn1:"optional,tag:1"`
+	SignerInfos      []asn1.RawValue `asn1:"set"`
+}
+
+// EncodePKCS7Certs encodes the certificates to DER PKCS#7 certs-only bundle,
+// which is also known as .p7b or .p7c file
+func EncodePKCS7Certs(certs []*x509.Certificate) ([]byte, error) {
+	var raw []byte
+	for _, cert := range certs {
+		raw = append(raw, cert.Raw...)
+	}
+
+	signedData, err := asn1.Marshal(pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{},
+		ContentInfo:      contentInfo{ContentType: oidDataContentType},
+		Certificates: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      raw,
+		},
+		SignerInfos: []asn1.RawValue{},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to marshal PKCS#7: %w", err)
+	}
+
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedDataContentType,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedData},
+	})
+}
+
+// DecodePKCS7Certs decodes the certificates from DER PKCS#7 bundle
+func DecodePKCS7Certs(data []byte) ([]*x509.Certificate, error) {
+	signedData, err := parsePKCS7SignedData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	certs, err := x509.ParseCertificates(signedData.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse PKCS#7 certificates: %w", err)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("Failed to parse PKCS#7: no certificates found")
+	}
+
+	return certs, nil
+}
+
+// IsPKCS7 returns true if the data is DER PKCS#7 signed data
+func IsPKCS7(data []byte) bool {
+	_, err := parsePKCS7SignedData(data)
+	return err == nil
+}
+
+func parsePKCS7SignedData(data []byte) (*pkcs7SignedData, error) {
+	var info contentInfo
+	if _, err := asn1.Unmarshal(data, &info); err != nil {
+		// some PKCS#7 files are BER encoded
+		der, berErr := berToDER(data)
+		if berErr != nil {
+			return nil, fmt.Errorf("Failed to parse PKCS#7: %w", err)
+		}
+		if _, err := asn1.Unmarshal(der, &info); err != nil {
+			return nil, fmt.Errorf("Failed to parse PKCS#7: %w", err)
+		}
+	}
+
+	if !info.ContentType.Equal(oidSignedDataContentType) {
+		return nil, fmt.Errorf("Failed to parse PKCS#7: unsupported content type %s", info.ContentType)
+	}
+
+	var signedData pkcs7SignedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &signedData); err != nil {
+		return nil, fmt.Errorf("Failed to parse PKCS#7 signed data: %w", err)
+	}
+
+	return &signedData, nil
+}
@@ -0,0 +1,355 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	EncryptedPrivateKeyBlockType = "ENCRYPTED PRIVATE KEY"
+
+	KDFPBKDF2 = "pbkdf2"
+	KDFScrypt = "scrypt"
+)
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+const (
+	pbkdf2Iterations = 100000
+	scryptN          = 1 << 14
+	scryptR          = 8
+	scryptP          = 1
+)
+
+// the max cost of the key derivation read from encrypted keys, the crafted
+// parameters can not pin the CPU or exhaust the memory
+const (
+	maxPBKDF2Iterations = 10000000
+	maxScryptN          = 1 << 20
+	// scrypt uses 128 * N * r bytes of memory
+	maxScryptMemory = 1 << 30
+	maxScryptP      = 16
+)
+
+var ErrPassphraseRequired = errors.New("private key is encrypted, passphrase required")
+
+// RFC 5958 section 3
+type encryptedPrivateKeyInfo struct {
+	Algo          pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// RFC 8018 appendix A.4
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// RFC 8018 appendix A.2
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// RFC 7914 section 7.1
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+// EncryptPKCS8PrivateKey encrypts the PKCS#8 private key with PBES2 and
+// AES-256-CBC, the key is derived from passphrase with PBKDF2 or scrypt
+func EncryptPKCS8PrivateKey(der, passphrase []byte, kdf string) ([]byte, error) {
+	algo, encrypted, err := pbes2Encrypt(der, passphrase, kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(encryptedPrivateKeyInfo{Algo: algo, EncryptedData: encrypted})
+}
+
+// DecryptPKCS8PrivateKey decrypts the encrypted PKCS#8 private key and returns
+// the PKCS#8 private key
+func DecryptPKCS8PrivateKey(der, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("Failed to parse encrypted private key: %w", err)
+	} else if len(rest) > 0 {
+		return nil, fmt.Errorf("Failed to parse encrypted private key: trailing data")
+	}
+
+	return pbeDecrypt(info.Algo, info.EncryptedData, passphrase)
+}
+
+// pbeDecrypt decrypts data with the password based encryption algorithm,
+// PBES2 and the legacy PKCS#12 PBE are supported
+func pbeDecrypt(algo pkix.AlgorithmIdentifier, data, passphrase []byte) ([]byte, error) {
+	if algo.Algorithm.Equal(oidPBES2) {
+		return pbes2Decrypt(algo, data, passphrase)
+	}
+
+	return pkcs12PBEDecrypt(algo, data, passphrase)
+}
+
+// GetKDF validates the key derivation function name, the empty name is pbkdf2
+func GetKDF(kdf string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(kdf)) {
+	case KDFPBKDF2, "":
+		return KDFPBKDF2, nil
+	case KDFScrypt:
+		return KDFScrypt, nil
+	}
+
+	return "", fmt.Errorf("Invalid key derivation function: %s, must be pbkdf2 or scrypt", kdf)
+}
+
+func pbes2Encrypt(data, passphrase []byte, kdf string) (pkix.AlgorithmIdentifier, []byte, error) {
+	kdf, err := GetKDF(kdf)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	var key []byte
+	var kdfAlgo pkix.AlgorithmIdentifier
+	switch kdf {
+	case KDFPBKDF2:
+		key = pbkdf2.Key(passphrase, salt, pbkdf2Iterations, 32, sha256.New)
+
+		params, err := asn1.Marshal(pbkdf2Params{
+			Salt:           salt,
+			IterationCount: pbkdf2Iterations,
+			PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+		})
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, nil, err
+		}
+		kdfAlgo = pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: params}}
+	case KDFScrypt:
+		key, err = scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, 32)
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, nil, err
+		}
+
+		params, err := asn1.Marshal(scryptParams{
+			Salt:                     salt,
+			CostParameter:            scryptN,
+			BlockSize:                scryptR,
+			ParallelizationParameter: scryptP,
+		})
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, nil, err
+		}
+		kdfAlgo = pkix.AlgorithmIdentifier{Algorithm: oidScrypt, Parameters: asn1.RawValue{FullBytes: params}}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	encrypted := pkcs7Pad(data, block.BlockSize())
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+
+	ivBytes, err := asn1.Marshal(iv)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: kdfAlgo,
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivBytes}},
+	})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	return pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}}, encrypted, nil
+}
+
+func pbes2Decrypt(algo pkix.AlgorithmIdentifier, data, passphrase []byte) ([]byte, error) {
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(algo.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("Failed to parse PBES2 parameters: %w", err)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("Failed to parse PBES2 encryption scheme: %w", err)
+	}
+
+	var keyLen int
+	var newCipher func([]byte) (cipher.Block, error)
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLen, newCipher = 16, aes.NewCipher
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keyLen, newCipher = 24, aes.NewCipher
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLen, newCipher = 32, aes.NewCipher
+	case params.EncryptionScheme.Algorithm.Equal(oidDESEDE3CBC):
+		keyLen, newCipher = 24, des.NewTripleDESCipher
+	default:
+		return nil, fmt.Errorf("Unsupported PBES2 encryption scheme: %s", params.EncryptionScheme.Algorithm)
+	}
+
+	var key []byte
+	kdf := params.KeyDerivationFunc
+	switch {
+	case kdf.Algorithm.Equal(oidPBKDF2):
+		var p pbkdf2Params
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &p); err != nil {
+			return nil, fmt.Errorf("Failed to parse PBKDF2 parameters: %w", err)
+		}
+
+		prf, err := getPRF(p.PRF)
+		if err != nil {
+			return nil, err
+		}
+		if p.IterationCount < 1 || p.IterationCount > maxPBKDF2Iterations {
+			return nil, fmt.Errorf("Invalid PBKDF2 iteration count: %d, must be between 1 and %d", p.IterationCount, maxPBKDF2Iterations)
+		}
+		key = pbkdf2.Key(passphrase, p.Salt, p.IterationCount, keyLen, prf)
+	case kdf.Algorithm.Equal(oidScrypt):
+		var p scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &p); err != nil {
+			return nil, fmt.Errorf("Failed to parse scrypt parameters: %w", err)
+		}
+
+		if err := checkScryptParams(p); err != nil {
+			return nil, err
+		}
+
+		var err error
+		key, err = scrypt.Key(passphrase, p.Salt, p.CostParameter, p.BlockSize, p.ParallelizationParameter, keyLen)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported PBES2 key derivation function: %s", kdf.Algorithm)
+	}
+
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cbcDecrypt(block, iv, data)
+}
+
+// checkScryptParams checks the scrypt cost parameters are within the limits
+func checkScryptParams(p scryptParams) error {
+	n, r := p.CostParameter, p.BlockSize
+	if n < 2 || n > maxScryptN {
+		return fmt.Errorf("Invalid scrypt cost parameter N: %d, must be between 2 and %d", n, maxScryptN)
+	}
+	if r < 1 || 128*n*r > maxScryptMemory {
+		return fmt.Errorf("Invalid scrypt block size r: %d, N=%d r=%d needs more than %d MiB memory", r, n, r, maxScryptMemory>>20)
+	}
+	if p.ParallelizationParameter < 1 || p.ParallelizationParameter > maxScryptP {
+		return fmt.Errorf("Invalid scrypt parallelization parameter p: %d, must be between 1 and %d", p.ParallelizationParameter, maxScryptP)
+	}
+
+	return nil
+}
+
+func getPRF(prf pkix.AlgorithmIdentifier) (func() hash.Hash, error) {
+	switch {
+	// hmacWithSHA1 is the default
+	case len(prf.Algorithm) == 0, prf.Algorithm.Equal(oidHMACWithSHA1):
+		return sha1.New, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA256):
+		return sha256.New, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA384):
+		return sha512.New384, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA512):
+		return sha512.New, nil
+	}
+
+	return nil, fmt.Errorf("Unsupported PBKDF2 pseudorandom function: %s", prf.Algorithm)
+}
+
+func cbcDecrypt(block cipher.Block, iv, data []byte) ([]byte, error) {
+	if len(iv) != block.BlockSize() {
+		return nil, fmt.Errorf("Invalid IV length: %d", len(iv))
+	}
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("Invalid encrypted data length: %d", len(data))
+	}
+
+	decrypted := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, data)
+
+	return pkcs7Unpad(decrypted, block.BlockSize())
+}
+
+func pkcs7Pad(data []byte, blockSize int) []byte {
+	n := blockSize - len(data)%blockSize
+	padded := make([]byte, len(data)+n)
+	copy(padded, data)
+	for i := len(data); i < len(padded); i++ {
+		padded[i] = byte(n)
+	}
+	return padded
+}
+
+// a wrong passphrase almost always results in invalid padding
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, error) {
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize || n > len(data) {
+		return nil, fmt.Errorf("Failed to decrypt, incorrect passphrase")
+	}
+
+	padding := make([]byte, n)
+	for i := range padding {
+		padding[i] = byte(n)
+	}
+	if !bytes.Equal(data[len(data)-n:], padding) {
+		return nil, fmt.Errorf("Failed to decrypt, incorrect passphrase")
+	}
+
+	return data[:len(data)-n], nil
+}
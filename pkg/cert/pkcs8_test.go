@@ -0,0 +1,157 @@
+package cert
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncodeEncryptedKey(t *testing.T) {
+	keyAlg, err := NewKeyAlgorithm(KeyTypeECDSA, 0, "P-256")
+	if err != nil {
+		t.Fatalf("failed NewKeyAlgorithm: %v", err)
+	}
+
+	key, err := keyAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+
+	passphrase := []byte("secret")
+	for _, kdf := range []string{KDFPBKDF2, KDFScrypt} {
+		keyBytes, err := EncodeEncryptedKey(key, passphrase, kdf)
+		if err != nil {
+			t.Fatalf("failed EncodeEncryptedKey with %s: %v", kdf, err)
+		}
+
+		if _, err := ParseKey(keyBytes); !errors.Is(err, ErrPassphraseRequired) {
+			t.Errorf("failed ParseKey with %s:\n\tactual: %v\n\texpect: %v\n", kdf, err, ErrPassphraseRequired)
+		}
+
+		if _, err := ParseKeyWithPassphrase(keyBytes, []byte("wrong")); err == nil {
+			t.Errorf("failed ParseKeyWithPassphrase with %s: expect error for wrong passphrase", kdf)
+		}
+
+		signer, err := ParseSigner(keyBytes, passphrase)
+		if err != nil {
+			t.Fatalf("failed ParseSigner with %s: %v", kdf, err)
+		}
+
+		if !PublicKeyEqual(signer.Public(), key.Public()) {
+			t.Errorf("failed ParseSigner with %s: public key mismatch", kdf)
+		}
+	}
+}
+
+func TestDecryptPKCS8PrivateKeyLimits(t *testing.T) {
+	// tamper rewrites the KDF parameters of the encrypted key
+	tamper := func(der []byte, params interface{}) []byte {
+		var info encryptedPrivateKeyInfo
+		var pbes2 pbes2Params
+		if _, err := asn1.Unmarshal(der, &info); err != nil {
+			t.Fatalf("failed Unmarshal: %v", err)
+		}
+		if _, err := asn1.Unmarshal(info.Algo.Parameters.FullBytes, &pbes2); err != nil {
+			t.Fatalf("failed Unmarshal: %v", err)
+		}
+		paramsBytes, _ := asn1.Marshal(params)
+		pbes2.KeyDerivationFunc.Parameters = asn1.RawValue{FullBytes: paramsBytes}
+		pbes2Bytes, _ := asn1.Marshal(pbes2)
+		info.Algo.Parameters = asn1.RawValue{FullBytes: pbes2Bytes}
+		der, _ = asn1.Marshal(info)
+		return der
+	}
+
+	passphrase := []byte("secret")
+	pbkdf2DER, err := EncryptPKCS8PrivateKey([]byte("key"), passphrase, KDFPBKDF2)
+	if err != nil {
+		t.Fatalf("failed EncryptPKCS8PrivateKey: %v", err)
+	}
+	scryptDER, err := EncryptPKCS8PrivateKey([]byte("key"), passphrase, KDFScrypt)
+	if err != nil {
+		t.Fatalf("failed EncryptPKCS8PrivateKey: %v", err)
+	}
+	salt := []byte("saltsalt")
+
+	var tests = []struct {
+		name   string
+		der    []byte
+		errMsg string
+	}{
+		{name: "pbkdf2", der: pbkdf2DER},
+		{name: "scrypt", der: scryptDER},
+		{name: "pbkdf2 iterations", der: tamper(pbkdf2DER, pbkdf2Params{Salt: salt, IterationCount: maxPBKDF2Iterations + 1}), errMsg: "Invalid PBKDF2 iteration count"},
+		{name: "scrypt N", der: tamper(scryptDER, scryptParams{Salt: salt, CostParameter: 1 << 21, BlockSize: 8, ParallelizationParameter: 1}), errMsg: "Invalid scrypt cost parameter N"},
+		{name: "scrypt memory", der: tamper(scryptDER, scryptParams{Salt: salt, CostParameter: 1 << 20, BlockSize: 16, ParallelizationParameter: 1}), errMsg: "Invalid scrypt block size r"},
+		{name: "scrypt p", der: tamper(scryptDER, scryptParams{Salt: salt, CostParameter: 1 << 14, BlockSize: 8, ParallelizationParameter: 1 << 20}), errMsg: "Invalid scrypt parallelization parameter p"},
+	}
+
+	for _, test := range tests {
+		data, err := DecryptPKCS8PrivateKey(test.der, passphrase)
+		if test.errMsg == "" {
+			if err != nil || string(data) != "key" {
+				t.Errorf("failed DecryptPKCS8PrivateKey %s: %q %v", test.name, data, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.errMsg) {
+			t.Errorf("failed DecryptPKCS8PrivateKey %s:\n\tactual: %v\n\texpect: %s\n", test.name, err, test.errMsg)
+		}
+	}
+}
+
+func TestGetKDF(t *testing.T) {
+	var tests = []struct {
+		kdf     string
+		expect  string
+		invalid bool
+	}{
+		{kdf: "", expect: KDFPBKDF2},
+		{kdf: "PBKDF2", expect: KDFPBKDF2},
+		{kdf: " scrypt", expect: KDFScrypt},
+		{kdf: "bogus", invalid: true},
+	}
+
+	for _, test := range tests {
+		actual, err := GetKDF(test.kdf)
+		if (err != nil) != test.invalid || actual != test.expect {
+			t.Errorf("failed GetKDF %q:\n\tactual: %s %v\n\texpect: %s\n", test.kdf, actual, err, test.expect)
+		}
+	}
+}
+
+func TestParseLegacyEncryptedKey(t *testing.T) {
+	keyAlg, err := NewKeyAlgorithm(KeyTypeRSA, 2048, "")
+	if err != nil {
+		t.Fatalf("failed NewKeyAlgorithm: %v", err)
+	}
+
+	key, err := keyAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+
+	keyBytes, err := EncodeKey(key)
+	if err != nil {
+		t.Fatalf("failed EncodeKey: %v", err)
+	}
+
+	block, _ := pem.Decode(keyBytes)
+	encrypted, err := x509.EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, []byte("secret"), x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatalf("failed EncryptPEMBlock: %v", err)
+	}
+
+	signer, err := ParseSigner(pem.EncodeToMemory(encrypted), []byte("secret"))
+	if err != nil {
+		t.Fatalf("failed ParseSigner: %v", err)
+	}
+
+	if !PublicKeyEqual(signer.Public(), key.Public()) {
+		t.Errorf("failed ParseSigner: public key mismatch")
+	}
+}
@@ -0,0 +1,118 @@
+package cert
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RFC 5280 section 4.2.1.12, the key usage bits consistent with the purpose,
+// one of them is required if the key usage extension is present
+var purposeKeyUsages = map[x509.ExtKeyUsage]x509.KeyUsage{
+	x509.ExtKeyUsageServerAuth:      x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageKeyAgreement,
+	x509.ExtKeyUsageClientAuth:      x509.KeyUsageDigitalSignature | x509.KeyUsageKeyAgreement,
+	x509.ExtKeyUsageCodeSigning:     x509.KeyUsageDigitalSignature,
+	x509.ExtKeyUsageEmailProtection: x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment | x509.KeyUsageKeyEncipherment | x509.KeyUsageKeyAgreement,
+	x509.ExtKeyUsageTimeStamping:    x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+	x509.ExtKeyUsageOCSPSigning:     x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+}
+
+// PurposeCheck is the result of checking the leaf certificate for a purpose,
+// the extended key usage and key usage are checked separately, and the
+// ChainExtKeyUsage is set if the intermediates are checked too
+type PurposeCheck struct {
+	Purpose          string       `json:"purpose" yaml:"purpose"`
+	ExtKeyUsage      *VerifyCheck `json:"extKeyUsage" yaml:"extKeyUsage"`
+	KeyUsage         *VerifyCheck `json:"keyUsage" yaml:"keyUsage"`
+	ChainExtKeyUsage *VerifyCheck `json:"chainExtKeyUsage,omitempty" yaml:"chainExtKeyUsage,omitempty"`
+}
+
+// ParsePurpose returns the extended key usage of purpose name, the names are
+// the same as --ext-usage of create, e.g. serverAuth or codeSigning
+func ParsePurpose(s string) (x509.ExtKeyUsage, error) {
+	for k, v := range ekuStringToAction {
+		if strings.EqualFold(s, k) {
+			return v, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Invalid purpose: %s", s)
+}
+
+// CheckPurpose checks the extended key usage and key usage of certificate
+// allow the purpose
+func CheckPurpose(cert *x509.Certificate, purpose x509.ExtKeyUsage) *PurposeCheck {
+	return &PurposeCheck{
+		Purpose:     purposeName(purpose),
+		ExtKeyUsage: NewVerifyCheck(checkExtKeyUsage(cert, purpose)),
+		KeyUsage:    NewVerifyCheck(checkKeyUsage(cert, purpose)),
+	}
+}
+
+// checkExtKeyUsage checks the purpose is in the extended key usages, no
+// extended key usage extension means any purpose
+func checkExtKeyUsage(cert *x509.Certificate, purpose x509.ExtKeyUsage) error {
+	if purpose == x509.ExtKeyUsageAny || (len(cert.ExtKeyUsage) == 0 && len(cert.UnknownExtKeyUsage) == 0) {
+		return nil
+	}
+
+	var usages []string
+	for _, eku := range cert.ExtKeyUsage {
+		if eku == purpose || eku == x509.ExtKeyUsageAny {
+			return nil
+		}
+		usages = append(usages, purposeName(eku))
+	}
+	for _, oid := range cert.UnknownExtKeyUsage {
+		usages = append(usages, oid.String())
+	}
+
+	return fmt.Errorf("the extended key usage does not allow %s, only %s", purposeName(purpose), strings.Join(usages, ", "))
+}
+
+// checkKeyUsage checks one of the key usage bits consistent with the purpose
+// is set, the keyEncipherment is only used by RSA key exchange
+func checkKeyUsage(cert *x509.Certificate, purpose x509.ExtKeyUsage) error {
+	allowed, ok := purposeKeyUsages[purpose]
+	if !ok || cert.KeyUsage == 0 {
+		return nil
+	}
+
+	note := ""
+	if _, isRSA := cert.PublicKey.(*rsa.PublicKey); !isRSA && allowed&x509.KeyUsageKeyEncipherment != 0 {
+		allowed &^= x509.KeyUsageKeyEncipherment
+		if cert.KeyUsage&x509.KeyUsageKeyEncipherment != 0 {
+			note = ", Key Encipherment is only used by RSA key"
+		}
+	}
+	if cert.KeyUsage&allowed != 0 {
+		return nil
+	}
+
+	return fmt.Errorf("the key usage %s is not consistent with %s, expect %s%s",
+		strings.Join(keyUsageNames(cert.KeyUsage), ", "), purposeName(purpose), strings.Join(keyUsageNames(allowed), " or "), note)
+}
+
+func purposeName(eku x509.ExtKeyUsage) string {
+	for k, v := range ekuStringToAction {
+		if v == eku {
+			return k
+		}
+	}
+
+	return ekuActionToString[eku]
+}
+
+func keyUsageNames(ku x509.KeyUsage) []string {
+	var names []string
+	for key, name := range kuActionToString {
+		if key&ku == key {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
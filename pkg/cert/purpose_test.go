@@ -0,0 +1,76 @@
+package cert
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+)
+
+func TestParsePurpose(t *testing.T) {
+	var tests = []struct {
+		purpose string
+		expect  x509.ExtKeyUsage
+		err     bool
+	}{
+		{purpose: "serverAuth", expect: x509.ExtKeyUsageServerAuth},
+		{purpose: "clientauth", expect: x509.ExtKeyUsageClientAuth},
+		{purpose: "codeSigning", expect: x509.ExtKeyUsageCodeSigning},
+		{purpose: "emailProtection", expect: x509.ExtKeyUsageEmailProtection},
+		{purpose: "any", expect: x509.ExtKeyUsageAny},
+		{purpose: "smime", err: true},
+	}
+
+	for _, test := range tests {
+		actual, err := ParsePurpose(test.purpose)
+		if (err != nil) != test.err || actual != test.expect {
+			t.Errorf("failed ParsePurpose %s:\n\tactual: %v %v\n\texpect: %v\n", test.purpose, actual, err, test.expect)
+		}
+	}
+}
+
+func TestCheckPurpose(t *testing.T) {
+	ecAlg, _ := NewKeyAlgorithm(KeyTypeECDSA, 0, "P-256")
+	ecKey, err := ecAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+	rsaAlg, _ := NewKeyAlgorithm(KeyTypeRSA, 2048, "")
+	rsaKey, err := rsaAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+
+	var tests = []struct {
+		rsa      bool
+		usage    string
+		extUsage string
+		purpose  x509.ExtKeyUsage
+		ekuErr   string
+		kuErr    string
+	}{
+		{usage: "digitalSignature", extUsage: "serverAuth", purpose: x509.ExtKeyUsageServerAuth},
+		{usage: "", extUsage: "", purpose: x509.ExtKeyUsageCodeSigning},
+		{usage: "digitalSignature", extUsage: "serverAuth", purpose: x509.ExtKeyUsageClientAuth, ekuErr: "does not allow clientAuth, only serverAuth"},
+		{usage: "digitalSignature", extUsage: "any", purpose: x509.ExtKeyUsageEmailProtection},
+		{rsa: true, usage: "keyEncipherment", extUsage: "serverAuth", purpose: x509.ExtKeyUsageServerAuth},
+		{usage: "keyEncipherment", extUsage: "serverAuth", purpose: x509.ExtKeyUsageServerAuth, kuErr: "only used by RSA key"},
+		{usage: "keyEncipherment", extUsage: "codeSigning", purpose: x509.ExtKeyUsageCodeSigning, kuErr: "expect Digital Signature"},
+		{usage: "keyCertSign", extUsage: "", purpose: x509.ExtKeyUsageAny},
+	}
+
+	for _, test := range tests {
+		key := ecKey
+		if test.rsa {
+			key = rsaKey
+		}
+		cert, _ := newTestCert(t, nil, nil, testCertOptions{subject: "CN=china.com", san: "china.com", usage: test.usage, extUsage: test.extUsage, key: key})
+
+		check := CheckPurpose(cert, test.purpose)
+		if check.ExtKeyUsage.Verified != (test.ekuErr == "") || !strings.Contains(check.ExtKeyUsage.Error, test.ekuErr) {
+			t.Errorf("failed CheckPurpose %s %s extKeyUsage:\n\tactual: %s\n\texpect: %s\n", test.usage, test.extUsage, check.ExtKeyUsage.Error, test.ekuErr)
+		}
+		if check.KeyUsage.Verified != (test.kuErr == "") || !strings.Contains(check.KeyUsage.Error, test.kuErr) {
+			t.Errorf("failed CheckPurpose %s %s keyUsage:\n\tactual: %s\n\texpect: %s\n", test.usage, test.extUsage, check.KeyUsage.Error, test.kuErr)
+		}
+	}
+}
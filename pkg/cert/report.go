@@ -0,0 +1,89 @@
+package cert
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The status of report check
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// The checks of verification report, in the order of report
+const (
+	ReportChain    = "chain"
+	ReportValidity = "validity"
+	ReportHostname = "hostname"
+	ReportPurpose  = "purpose"
+	ReportKey      = "key"
+	ReportCSR      = "csr"
+	ReportCRL      = "crl"
+	ReportOCSP     = "ocsp"
+)
+
+// the width of check name in text report
+const reportNameWidth = 8
+
+// The exit codes of verification, the exit code of the first failed check
+// in the report is used
+const (
+	ExitOK                = 0
+	ExitError             = 1
+	ExitChain             = 2
+	ExitValidity          = 3
+	ExitHostname          = 4
+	ExitPurpose           = 5
+	ExitMismatch          = 6
+	ExitRevoked           = 7
+	ExitRevocationUnknown = 8
+)
+
+// ReportCheck is one check of verification report, the ExitCode is set if
+// the check failed
+type ReportCheck struct {
+	Name     string `json:"name" yaml:"name"`
+	Status   string `json:"status" yaml:"status"`
+	Reason   string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	ExitCode int    `json:"exitCode,omitempty" yaml:"exitCode,omitempty"`
+}
+
+// PassCheck returns the passed check
+func PassCheck(name, reason string) *ReportCheck {
+	return &ReportCheck{Name: name, Status: StatusPass, Reason: reason}
+}
+
+// FailCheck returns the failed check with the exit code
+func FailCheck(name string, exitCode int, reason string) *ReportCheck {
+	return &ReportCheck{Name: name, Status: StatusFail, Reason: reason, ExitCode: exitCode}
+}
+
+// SkipCheck returns the skipped check
+func SkipCheck(name, reason string) *ReportCheck {
+	return &ReportCheck{Name: name, Status: StatusSkip, Reason: reason}
+}
+
+// GetReportExitCode returns the exit code of the first failed check, or
+// ExitOK if no check failed
+func GetReportExitCode(checks []*ReportCheck) int {
+	for _, c := range checks {
+		if c.Status == StatusFail {
+			return c.ExitCode
+		}
+	}
+
+	return ExitOK
+}
+
+// GetReportText returns one line per check, like "PASS  chain     reason"
+func GetReportText(checks []*ReportCheck) string {
+	var b strings.Builder
+
+	for _, c := range checks {
+		fmt.Fprintf(&b, "%-4s  %-*s  %s\n", strings.ToUpper(c.Status), reportNameWidth, c.Name, c.Reason)
+	}
+
+	return b.String()
+}
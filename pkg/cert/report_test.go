@@ -0,0 +1,38 @@
+package cert
+
+import (
+	"testing"
+)
+
+func TestGetReportExitCode(t *testing.T) {
+	var tests = []struct {
+		checks []*ReportCheck
+		expect int
+	}{
+		{checks: nil, expect: ExitOK},
+		{checks: []*ReportCheck{PassCheck(ReportChain, ""), SkipCheck(ReportHostname, "")}, expect: ExitOK},
+		{checks: []*ReportCheck{PassCheck(ReportChain, ""), FailCheck(ReportValidity, ExitValidity, "")}, expect: ExitValidity},
+		{checks: []*ReportCheck{FailCheck(ReportHostname, ExitHostname, ""), FailCheck(ReportCRL, ExitRevoked, "")}, expect: ExitHostname},
+	}
+
+	for i, test := range tests {
+		if actual := GetReportExitCode(test.checks); actual != test.expect {
+			t.Errorf("failed GetReportExitCode %d:\n\tactual: %d\n\texpect: %d\n", i, actual, test.expect)
+		}
+	}
+}
+
+func TestGetReportText(t *testing.T) {
+	checks := []*ReportCheck{
+		PassCheck(ReportChain, "1 chain(s) found to the trusted roots"),
+		FailCheck(ReportHostname, ExitHostname, "the certificate does not match a.com"),
+		SkipCheck(ReportOCSP, "--ocsp or --ocsp-url is not provided"),
+	}
+
+	expect := "PASS  chain     1 chain(s) found to the trusted roots\n" +
+		"FAIL  hostname  the certificate does not match a.com\n" +
+		"SKIP  ocsp      --ocsp or --ocsp-url is not provided\n"
+	if actual := GetReportText(checks); actual != expect {
+		t.Errorf("failed GetReportText:\n\tactual: %s\n\texpect: %s\n", actual, expect)
+	}
+}
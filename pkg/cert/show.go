@@ -47,7 +47,7 @@ var extensionIDToName = map[string]string{
 	"2.5.29.30": "Name Constraints",
 	"2.5.29.36": "Policy Constraints",
 	// "2.5.29.37": "Extended Key Usage",
-	"2.5.29.54": "Inhibit anyPolic",
+	"2.5.29.54": "Inhibit anyPolicy",
 
 	// Per RFC 5280 section 4.2, SHOULD recognize extensions
 	"2.5.29.35": "Authority Key Identifier",
@@ -56,7 +56,12 @@ var extensionIDToName = map[string]string{
 }
 
 func GetCertRequestInfo(bytes []byte) ([]map[string]string, error) {
-	block, _ := pem.Decode(bytes)
+	pemBytes, err := ToPEM(bytes, "", CertReqBlockType)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse certificate request: %w", err)
+	}
+
+	block, _ := pem.Decode(pemBytes)
 	if block == nil {
 		return nil, fmt.Errorf("Failed to parse certificate request")
 	}
@@ -69,22 +74,19 @@ func GetCertRequestInfo(bytes []byte) ([]map[string]string, error) {
 		return nil, fmt.Errorf("Failed to parse certificate request")
 	}
 
+	return formatCertificateRequestInfo(NewCertificateRequestInfo(csr)), nil
+}
+
+// formatCertificateRequestInfo returns the human readable info of certificate request
+func formatCertificateRequestInfo(info *CertificateRequestInfo) []map[string]string {
 	var result []map[string]string
-	if csr.Subject.String() != "" {
+	if info.Subject.DN != "" {
 		result = append(result, map[string]string{
-			"Subject": csr.Subject.String(),
+			"Subject": info.Subject.DN,
 		})
 	}
 
-	var san []string
-	if len(csr.DNSNames) > 0 {
-		san = csr.DNSNames
-	}
-	if len(csr.IPAddresses) > 0 {
-		for _, ip := range csr.IPAddresses {
-			san = append(san, ip.String())
-		}
-	}
+	san := info.SANs.All()
 	if len(san) > 0 {
 		sort.Strings(san)
 		result = append(result, map[string]string{
@@ -92,118 +94,346 @@ func GetCertRequestInfo(bytes []byte) ([]map[string]string, error) {
 		})
 	}
 
-	return result, nil
+	if info.PublicKey != nil {
+		publicKey := fmt.Sprintf("%s %d bit", info.PublicKey.Algorithm, info.PublicKey.Size)
+		if info.PublicKey.Curve != "" {
+			publicKey += " " + info.PublicKey.Curve
+		}
+		result = append(result, map[string]string{
+			"Public Key": publicKey,
+		})
+	} else {
+		result = append(result, map[string]string{
+			"Public Key": info.PublicKeyAlgorithm,
+		})
+	}
+
+	result = append(result, map[string]string{
+		"Signature Algorithm": info.SignatureAlgorithm,
+	})
+
+	signature := "OK"
+	if !info.Signature.Verified {
+		signature = "FAILED, " + info.Signature.Error
+	}
+	result = append(result, map[string]string{
+		"Signature": signature,
+	})
+
+	if len(info.KeyUsages) > 0 {
+		result = append(result, map[string]string{
+			"Key Usage": strings.Join(info.KeyUsages, ", "),
+		})
+	}
+	if len(info.ExtKeyUsages) > 0 {
+		result = append(result, map[string]string{
+			"Extended Key Usage": strings.Join(info.ExtKeyUsages, ", "),
+		})
+	}
+	if bc := info.BasicConstraints; bc != nil {
+		constraints := "CA:FALSE"
+		if bc.IsCA {
+			constraints = "CA:TRUE"
+		}
+		if bc.IsCA && bc.MaxPathLen >= 0 {
+			constraints += fmt.Sprintf(", pathlen:%d", bc.MaxPathLen)
+		}
+		result = append(result, map[string]string{
+			"Basic Constraints": constraints,
+		})
+	}
+
+	var extensions []string
+	for _, e := range info.Extensions {
+		name := e.Name
+		if name == "" {
+			name = e.ID
+		}
+		if e.Critical {
+			name += " (critical)"
+		}
+		extensions = append(extensions, name)
+	}
+	if len(extensions) > 0 {
+		result = append(result, map[string]string{
+			"Requested Extensions": strings.Join(extensions, ", "),
+		})
+	}
+
+	if info.ChallengePassword != "" {
+		result = append(result, map[string]string{
+			"Challenge Password": info.ChallengePassword,
+		})
+	}
+	for _, a := range info.Attributes {
+		name := a.Name
+		if name == "" {
+			name = a.ID
+		}
+		result = append(result, map[string]string{
+			name: strings.Join(a.Values, ", "),
+		})
+	}
+
+	return result
 }
 
-func GetCertInfo(certBytes []byte) ([]map[string]string, error) {
-	certs, err := ParseCerts(certBytes)
+// formatCRLInfo returns the human readable info of certificate revocation list
+func formatCRLInfo(info *CRLInfo) []map[string]string {
+	var result []map[string]string
+
+	result = append(result, map[string]string{
+		"Issuer": info.Issuer.DN,
+	})
+	if info.Number != "" {
+		result = append(result, map[string]string{
+			"CRL Number": info.Number,
+		})
+	}
+	result = append(result, map[string]string{
+		"Last Update": info.ThisUpdate.String(),
+	})
+	if !info.NextUpdate.IsZero() {
+		result = append(result, map[string]string{
+			"Next Update": info.NextUpdate.String(),
+		})
+	}
+	result = append(result, map[string]string{
+		"Revoked Certificates": fmt.Sprint(info.RevokedCount),
+	})
+	result = append(result, map[string]string{
+		"Signature Algorithm": info.SignatureAlgorithm,
+	})
+
+	return result
+}
+
+// GetBundleInfo returns the human readable info of every PEM block of mixed
+// PEM bundle and which private key matches which certificate
+func GetBundleInfo(pemBytes, passphrase []byte) ([]map[string]string, error) {
+	entries, err := ParseBundle(pemBytes, passphrase)
 	if err != nil {
 		return nil, err
 	}
 
 	var result []map[string]string
 
-	if len(certs) > 1 {
+	result = append(result, map[string]string{
+		fmt.Sprintf("%d PEM blocks found", len(entries)): "",
+	})
+
+	for _, entry := range entries {
 		result = append(result, map[string]string{
-			fmt.Sprintf("%d certificates found", len(certs)): "",
+			"\n==================": fmt.Sprintf("Block %d: %s", entry.Block, entry.Type),
 		})
-	}
 
-	index := 1
-	for _, cert := range certs {
-		if len(certs) > 1 {
+		if entry.Err != nil {
 			result = append(result, map[string]string{
-				"\n==================": fmt.Sprintf("Certificate Number %d", index),
+				"Error": entry.Err.Error(),
 			})
-			index = index + 1
+			continue
 		}
-		if cert.Subject.String() != cert.Issuer.String() {
-			result = append(result, map[string]string{
-				"Issuer": cert.Issuer.String(),
-			})
+
+		switch {
+		case entry.Cert != nil:
+			result = append(result, formatCertificateInfo(NewCertificateInfo(entry.Cert))...)
+		case entry.CSR != nil:
+			result = append(result, formatCertificateRequestInfo(NewCertificateRequestInfo(entry.CSR))...)
+		case entry.CRL != nil:
+			result = append(result, formatCRLInfo(NewCRLInfo(entry.CRL))...)
+		case entry.Key != nil, entry.PublicKey != nil:
+			var info *KeyInfo
+			if entry.Key != nil {
+				info, err = NewPrivateKeyInfo(entry.Key)
+			} else {
+				info, err = NewPublicKeyInfo(entry.PublicKey)
+			}
+			if err != nil {
+				result = append(result, map[string]string{
+					"Error": err.Error(),
+				})
+				continue
+			}
+			result = append(result, formatKeyInfo(info)...)
 		}
+	}
 
-		if cert.Subject.String() != "" {
+	hasKey := false
+	for _, entry := range entries {
+		hasKey = hasKey || entry.Key != nil
+	}
+
+	matches := GetKeyMatches(entries)
+	if hasKey {
+		result = append(result, map[string]string{
+			"\n==================": "Key Matches",
+		})
+		if len(matches) == 0 {
 			result = append(result, map[string]string{
-				"Subject": cert.Subject.String(),
+				"Key Match": "no private key matches any certificate",
 			})
 		}
-
-		var san []string
-		if len(cert.DNSNames) > 0 {
-			san = cert.DNSNames
-		}
-		if len(cert.IPAddresses) > 0 {
-			for _, ip := range cert.IPAddresses {
-				san = append(san, ip.String())
-			}
-		}
-		if len(san) > 0 {
-			sort.Strings(san)
+		for _, m := range matches {
 			result = append(result, map[string]string{
-				"Subject Alt Name": strings.Join(san, ", "),
+				"Key Match": fmt.Sprintf("private key of block %d matches certificate of block %d", m.Key, m.Cert),
 			})
 		}
+	}
+
+	return result, nil
+}
+
+// GetKeyInfo returns the human readable info of private key or public key,
+// the passphrase is only used for encrypted private key
+func GetKeyInfo(keyBytes, passphrase []byte) ([]map[string]string, error) {
+	info, err := ParseKeyInfo(keyBytes, passphrase)
+	if err != nil {
+		return nil, err
+	}
 
+	return formatKeyInfo(info), nil
+}
+
+// formatKeyInfo returns the human readable info of key
+func formatKeyInfo(info *KeyInfo) []map[string]string {
+	var result []map[string]string
+
+	if info.Type == KeyInfoPrivate {
 		result = append(result, map[string]string{
-			"Is CA": fmt.Sprint(cert.IsCA),
+			"Key Type": "Private Key",
 		})
-
+	} else {
 		result = append(result, map[string]string{
-			"Serial Number": formatSerial(cert.SerialNumber),
+			"Key Type": "Public Key",
 		})
+	}
+
+	result = append(result, map[string]string{
+		"Algorithm": info.Algorithm,
+	})
+	result = append(result, map[string]string{
+		"Key Size": fmt.Sprintf("%d bit", info.Size),
+	})
+	if info.Curve != "" {
 		result = append(result, map[string]string{
-			"Effective Date": cert.NotBefore.String(),
+			"Curve": info.Curve,
 		})
+	}
+	result = append(result, map[string]string{
+		"Public Key SHA-256": info.PublicKeySHA256,
+	})
+	result = append(result, map[string]string{
+		"SPKI SHA-256 Pin": info.SPKISHA256,
+	})
+
+	if info.Check != nil {
+		check := "OK"
+		if !info.Check.Verified {
+			check = "FAILED, " + info.Check.Error
+		}
 		result = append(result, map[string]string{
-			"Expiration Date": cert.NotAfter.String(),
+			"Consistency Check": check,
 		})
+	}
 
-		if cert.KeyUsage != 0 {
-			var ku []string
-			for key, value := range kuActionToString {
-				n := key & cert.KeyUsage
-				if n == key {
-					ku = append(ku, value)
-				}
-			}
+	return result
+}
+
+func GetCertInfo(certBytes []byte) ([]map[string]string, error) {
+	infos, err := GetCertificateInfos(certBytes)
+	if err != nil {
+		return nil, err
+	}
 
-			sort.Strings(ku)
+	var result []map[string]string
+
+	if len(infos) > 1 {
+		result = append(result, map[string]string{
+			fmt.Sprintf("%d certificates found", len(infos)): "",
+		})
+	}
+
+	for i, info := range infos {
+		if len(infos) > 1 {
 			result = append(result, map[string]string{
-				"Key Usage": strings.Join(ku, ", "),
+				"\n==================": fmt.Sprintf("Certificate Number %d", i+1),
 			})
 		}
-		if len(cert.ExtKeyUsage) > 0 {
-			var eku []string
-			for _, e := range cert.ExtKeyUsage {
-				// handle and ignore unknown EKU
-				for key, value := range ekuActionToString {
-					if key == e {
-						eku = append(eku, value)
-						break
-					}
-				}
-			}
 
-			sort.Strings(eku)
+		result = append(result, formatCertificateInfo(info)...)
+	}
+
+	return result, nil
+}
+
+// formatCertificateInfo returns the human readable info of certificate
+func formatCertificateInfo(info *CertificateInfo) []map[string]string {
+	var result []map[string]string
+
+	if info.Subject.DN != info.Issuer.DN {
+		result = append(result, map[string]string{
+			"Issuer": info.Issuer.DN,
+		})
+	}
+
+	if info.Subject.DN != "" {
+		result = append(result, map[string]string{
+			"Subject": info.Subject.DN,
+		})
+	}
+
+	var san []string
+	san = append(san, info.SANs.DNSNames...)
+	san = append(san, info.SANs.IPAddresses...)
+	if len(san) > 0 {
+		sort.Strings(san)
+		result = append(result, map[string]string{
+			"Subject Alt Name": strings.Join(san, ", "),
+		})
+	}
+
+	result = append(result, map[string]string{
+		"Is CA": fmt.Sprint(info.IsCA),
+	})
+
+	result = append(result, map[string]string{
+		"Serial Number": info.SerialNumber,
+	})
+	result = append(result, map[string]string{
+		"Effective Date": info.NotBefore.String(),
+	})
+	result = append(result, map[string]string{
+		"Expiration Date": info.NotAfter.String(),
+	})
+
+	if len(info.KeyUsages) > 0 {
+		result = append(result, map[string]string{
+			"Key Usage": strings.Join(info.KeyUsages, ", "),
+		})
+	}
+	if len(info.ExtKeyUsages) > 0 {
+		result = append(result, map[string]string{
+			"Extended Key Usage": strings.Join(info.ExtKeyUsages, ", "),
+		})
+	}
+
+	for _, e := range info.Extensions {
+		if name, ok := extensionIDToName[e.ID]; ok {
 			result = append(result, map[string]string{
-				"Extended Key Usage": strings.Join(eku, ", "),
+				name: fmt.Sprintf("Critical:%v", e.Critical),
 			})
 		}
-
-		if len(cert.Extensions) > 0 {
-			for _, e := range cert.Extensions {
-				for k, v := range extensionIDToName {
-					if k == e.Id.String() {
-						result = append(result, map[string]string{
-							v: fmt.Sprintf("Critical:%v", e.Critical),
-						})
-						break
-					}
-				}
-			}
-		}
 	}
 
-	return result, nil
+	result = append(result, map[string]string{
+		"SHA-256 Fingerprint": info.Fingerprints.SHA256,
+	})
+	result = append(result, map[string]string{
+		"SHA-1 Fingerprint": info.Fingerprints.SHA1,
+	})
+	result = append(result, map[string]string{
+		"SPKI SHA-256 Pin": info.Fingerprints.SPKISHA256,
+	})
+
+	return result
 }
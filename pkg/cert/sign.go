@@ -2,19 +2,34 @@ package cert
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/rand"
-	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"time"
 )
 
-func NewSignedCertKey(caCert *x509.Certificate, caKey interface{}, certInfo *CertInfo, rsaKeySize int) ([]byte, []byte, error) {
-	key, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
+func NewSignedCertKey(caCert *x509.Certificate, caKey interface{}, certInfo *CertInfo, keyAlg *KeyAlgorithm) ([]byte, []byte, error) {
+	key, err := keyAlg.GenerateKey()
 	if err != nil {
 		return nil, nil, err
 	}
 
+	certBytes, err := NewSignedCert(caCert, caKey, certInfo, key.Public())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyBytes, err := EncodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return certBytes, keyBytes, nil
+}
+
+// NewSignedCert signs a certificate for the public key with CA, the private key is not needed
+func NewSignedCert(caCert *x509.Certificate, caKey interface{}, certInfo *CertInfo, pub crypto.PublicKey) ([]byte, error) {
 	now := time.Now()
 	template := x509.Certificate{
 		SerialNumber:          certInfo.SerialNumber,
@@ -29,20 +44,15 @@ func NewSignedCertKey(caCert *x509.Certificate, caKey interface{}, certInfo *Cer
 		IPAddresses:           certInfo.IPAddrs,
 	}
 
-	certDERBytes, err := x509.CreateCertificate(rand.Reader, &template, caCert, key.Public(), caKey)
+	certDERBytes, err := x509.CreateCertificate(rand.Reader, &template, caCert, pub, caKey)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	certBuffer := bytes.Buffer{}
 	if err := pem.Encode(&certBuffer, &pem.Block{Type: CertBlockType, Bytes: certDERBytes}); err != nil {
-		return nil, nil, err
-	}
-
-	keyBuffer := bytes.Buffer{}
-	if err := pem.Encode(&keyBuffer, &pem.Block{Type: RSAKeyBlockType, Bytes: x509.MarshalPKCS1PrivateKey(key)}); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	return certBuffer.Bytes(), keyBuffer.Bytes(), err
+	return certBuffer.Bytes(), nil
 }
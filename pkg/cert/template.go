@@ -0,0 +1,114 @@
+package cert
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateFuncs are the helper functions of certificate template:
+//
+//	join SEP LIST          join the string list with separator
+//	sans .SANs             all the subject alternative names separated by ", "
+//	until TIME             the duration until time, like "89d 23h 59m"
+//	daysLeft TIME          the whole days until time, negative if passed
+//	date LAYOUT TIME       format the time with Go layout, like "2006-01-02"
+//	fingerprint ALG .      the sha256, sha1 or spki fingerprint of certificate
+//	plainHex HEX           remove the colons and lower the case of hex string
+var TemplateFuncs = template.FuncMap{
+	"join": func(sep string, list []string) string {
+		return strings.Join(list, sep)
+	},
+	"sans": func(sans SubjectAltNames) string {
+		return strings.Join(sans.All(), ", ")
+	},
+	"until": func(t time.Time) string {
+		return formatDuration(time.Until(t))
+	},
+	"daysLeft": func(t time.Time) int {
+		return int(time.Until(t).Hours() / 24)
+	},
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"fingerprint": func(alg string, info *CertificateInfo) (string, error) {
+		switch strings.ToLower(alg) {
+		case "sha256", "sha-256":
+			return info.Fingerprints.SHA256, nil
+		case "sha1", "sha-1":
+			return info.Fingerprints.SHA1, nil
+		case "spki", "pin":
+			return info.Fingerprints.SPKISHA256, nil
+		}
+		return "", fmt.Errorf("Invalid fingerprint algorithm: %s, must be sha256, sha1 or spki", alg)
+	},
+	"plainHex": func(s string) string {
+		return strings.ToLower(strings.ReplaceAll(s, ":", ""))
+	},
+}
+
+// All returns all the subject alternative names
+func (s SubjectAltNames) All() []string {
+	var all []string
+	all = append(all, s.DNSNames...)
+	all = append(all, s.IPAddresses...)
+	all = append(all, s.EmailAddresses...)
+	all = append(all, s.URIs...)
+
+	return all
+}
+
+// NewCertTemplate parses the template which is evaluated against CertificateInfo
+func NewCertTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("cert").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// ExecuteCertTemplate executes the template for each certificate, a newline
+// is appended if the output does not end with one
+func ExecuteCertTemplate(w io.Writer, tmpl *template.Template, infos []*CertificateInfo) error {
+	for _, info := range infos {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, info); err != nil {
+			return fmt.Errorf("Failed to execute template: %w", err)
+		}
+
+		s := b.String()
+		if !strings.HasSuffix(s, "\n") {
+			s += "\n"
+		}
+		if _, err := io.WriteString(w, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formatDuration formats the duration in days, hours and minutes
+func formatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+
+	if days > 0 {
+		return fmt.Sprintf("%s%dd %dh %dm", sign, days, hours, minutes)
+	}
+	if hours > 0 {
+		return fmt.Sprintf("%s%dh %dm", sign, hours, minutes)
+	}
+
+	return fmt.Sprintf("%s%dm", sign, minutes)
+}
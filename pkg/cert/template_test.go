@@ -0,0 +1,86 @@
+package cert
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	var tests = []struct {
+		duration time.Duration
+		expect   string
+	}{
+		{duration: 90*24*time.Hour + 2*time.Hour + 3*time.Minute, expect: "90d 2h 3m"},
+		{duration: 5*time.Hour + 30*time.Minute, expect: "5h 30m"},
+		{duration: 42 * time.Second, expect: "0m"},
+		{duration: -(2*24*time.Hour + time.Hour), expect: "-2d 1h 0m"},
+	}
+
+	for _, test := range tests {
+		if actual := formatDuration(test.duration); actual != test.expect {
+			t.Errorf("failed formatDuration:\n\tactual: %s\n\texpect: %s\n", actual, test.expect)
+		}
+	}
+}
+
+func TestExecuteCertTemplate(t *testing.T) {
+	infos := []*CertificateInfo{
+		{
+			Subject:      Name{CommonName: "china.com"},
+			SANs:         SubjectAltNames{DNSNames: []string{"china.com", "www.china.com"}, IPAddresses: []string{"1.1.1.1"}},
+			NotAfter:     time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC),
+			Fingerprints: Fingerprints{SHA256: "AB:CD", SHA1: "EF:01", SPKISHA256: "pin="},
+		},
+		{
+			Subject:  Name{CommonName: "ca"},
+			NotAfter: time.Now().Add(10*24*time.Hour + time.Hour),
+		},
+	}
+
+	var tests = []struct {
+		text   string
+		expect string
+	}{
+		{
+			text:   `{{.Subject.CommonName}} expires on {{date "2006-01-02" .NotAfter}}`,
+			expect: "china.com expires on 2030-01-02\nca expires on " + infos[1].NotAfter.Format("2006-01-02") + "\n",
+		},
+		{
+			text:   `{{sans .SANs}}|{{join ";" .SANs.DNSNames}}`,
+			expect: "china.com, www.china.com, 1.1.1.1|china.com;www.china.com\n|\n",
+		},
+		{
+			text:   `{{fingerprint "sha256" .}} {{fingerprint "sha1" . | plainHex}} {{fingerprint "spki" .}}` + "\n",
+			expect: "AB:CD ef01 pin=\n  \n",
+		},
+	}
+
+	for _, test := range tests {
+		tmpl, err := NewCertTemplate(test.text)
+		if err != nil {
+			t.Fatalf("failed NewCertTemplate: %v", err)
+		}
+
+		var b strings.Builder
+		if err := ExecuteCertTemplate(&b, tmpl, infos); err != nil {
+			t.Fatalf("failed ExecuteCertTemplate: %v", err)
+		}
+		if b.String() != test.expect {
+			t.Errorf("failed ExecuteCertTemplate:\n\tactual: %q\n\texpect: %q\n", b.String(), test.expect)
+		}
+	}
+
+	tmpl, _ := NewCertTemplate(`{{daysLeft .NotAfter}} {{until .NotAfter}}`)
+	var b strings.Builder
+	if err := ExecuteCertTemplate(&b, tmpl, infos[1:]); err != nil {
+		t.Fatalf("failed ExecuteCertTemplate: %v", err)
+	}
+	if !strings.HasPrefix(b.String(), "10 10d 0h") && !strings.HasPrefix(b.String(), "10 10d 1h") {
+		t.Errorf("failed ExecuteCertTemplate duration helpers: %q", b.String())
+	}
+
+	if _, err := NewCertTemplate(`{{.Subject`); err == nil {
+		t.Errorf("failed NewCertTemplate: expect error of invalid template")
+	}
+}
@@ -0,0 +1,421 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const textTimeFormat = "Jan _2 15:04:05 2006 MST"
+
+const (
+	oidExtensionSubjectKeyId          = "2.5.29.14"
+	oidExtensionSubjectAltName        = "2.5.29.17"
+	oidExtensionNameConstraints       = "2.5.29.30"
+	oidExtensionCRLDistributionPoints = "2.5.29.31"
+	oidExtensionCertificatePolicies   = "2.5.29.32"
+	oidExtensionPolicyMappings        = "2.5.29.33"
+	oidExtensionAuthorityKeyId        = "2.5.29.35"
+	oidExtensionPolicyConstraints     = "2.5.29.36"
+	oidExtensionInhibitAnyPolicy      = "2.5.29.54"
+	oidExtensionAuthorityInfoAccess   = "1.3.6.1.5.5.7.1.1"
+	oidExtensionSCTList               = "1.3.6.1.4.1.11129.2.4.2"
+)
+
+var (
+	oidPolicyQualifierCPS        = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 2, 1}
+	oidPolicyQualifierUserNotice = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 2, 2}
+)
+
+// the hash and signature algorithms of SCT, RFC 5246 section 7.4.1.4.1
+var sctHashAlgorithms = map[byte]string{0: "none", 1: "md5", 2: "sha1", 3: "sha224", 4: "sha256", 5: "sha384", 6: "sha512"}
+var sctSignatureAlgorithms = map[byte]string{0: "anonymous", 1: "rsa", 2: "dsa", 3: "ecdsa"}
+
+// RFC 5280 section 4.2.1.4
+type policyInformation struct {
+	Policy     asn1.ObjectIdentifier
+	Qualifiers []policyQualifierInfo `asn1:"optional"`
+}
+
+type policyQualifierInfo struct {
+	PolicyQualifierID asn1.ObjectIdentifier
+	Qualifier         asn1.RawValue
+}
+
+// RFC 5280 section 4.2.1.5
+type policyMapping struct {
+	IssuerDomainPolicy  asn1.ObjectIdentifier
+	SubjectDomainPolicy asn1.ObjectIdentifier
+}
+
+// RFC 5280 section 4.2.1.11
+type policyConstraints struct {
+	RequireExplicitPolicy int `asn1:"optional,tag:0,default:-1"`
+	InhibitPolicyMapping  int `asn1:"optional,tag:1,default:-1"`
+}
+
+// GetCertText returns the full text dump of certificate, which is similar
+// to the output of "openssl x509 -noout -text"
+func GetCertText(cert *x509.Certificate) string {
+	w := &textWriter{}
+
+	w.line(0, "Certificate:")
+	w.line(1, "Data:")
+	w.line(2, "Version: %d (%#x)", cert.Version, cert.Version-1)
+	w.line(2, "Serial Number:")
+	w.line(3, "%s", formatSerial(cert.SerialNumber))
+	w.line(2, "Signature Algorithm: %s", cert.SignatureAlgorithm)
+	w.line(2, "Issuer: %s", cert.Issuer)
+	w.line(2, "Validity")
+	w.line(3, "Not Before: %s", cert.NotBefore.UTC().Format(textTimeFormat))
+	w.line(3, "Not After : %s", cert.NotAfter.UTC().Format(textTimeFormat))
+	w.line(2, "Subject: %s", cert.Subject)
+	w.line(2, "Subject Public Key Info:")
+	w.publicKey(3, cert.PublicKeyAlgorithm, cert.PublicKey)
+
+	if len(cert.Extensions) > 0 {
+		w.line(2, "X509v3 extensions:")
+		for _, e := range cert.Extensions {
+			w.extension(3, cert, e.Id.String(), e.Critical, e.Value)
+		}
+	}
+
+	w.line(1, "Signature Algorithm: %s", cert.SignatureAlgorithm)
+	w.line(1, "Signature Value:")
+	w.hex(2, cert.Signature, 18)
+
+	return w.String()
+}
+
+type textWriter struct {
+	strings.Builder
+}
+
+func (w *textWriter) line(indent int, format string, a ...interface{}) {
+	w.WriteString(strings.Repeat("    ", indent))
+	fmt.Fprintf(w, format, a...)
+	w.WriteString("\n")
+}
+
+// hex writes the bytes as colon separated hex, width bytes per line
+func (w *textWriter) hex(indent int, b []byte, width int) {
+	for i := 0; i < len(b); i += width {
+		end := i + width
+		if end > len(b) {
+			end = len(b)
+		}
+
+		s := formatHex(b[i:end])
+		if end < len(b) {
+			s += ":"
+		}
+		w.line(indent, "%s", s)
+	}
+}
+
+func (w *textWriter) publicKey(indent int, algo x509.PublicKeyAlgorithm, pub interface{}) {
+	w.line(indent, "Public Key Algorithm: %s", algo)
+
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		w.line(indent+1, "Public-Key: (%d bit)", k.N.BitLen())
+		w.line(indent+1, "Modulus:")
+		// the leading zero makes the modulus positive, same as openssl
+		w.hex(indent+2, append([]byte{0}, k.N.Bytes()...), 15)
+		w.line(indent+1, "Exponent: %d (%#x)", k.E, k.E)
+	case *ecdsa.PublicKey:
+		w.line(indent+1, "Public-Key: (%d bit)", k.Curve.Params().BitSize)
+		w.line(indent+1, "pub:")
+		if point, err := k.ECDH(); err == nil {
+			w.hex(indent+2, point.Bytes(), 15)
+		}
+		w.line(indent+1, "NIST CURVE: %s", k.Curve.Params().Name)
+	case ed25519.PublicKey:
+		w.line(indent+1, "ED25519 Public-Key:")
+		w.line(indent+1, "pub:")
+		w.hex(indent+2, k, 15)
+	default:
+		w.line(indent+1, "Unable to decode public key: %T", pub)
+	}
+}
+
+func (w *textWriter) extension(indent int, cert *x509.Certificate, id string, critical bool, value []byte) {
+	name := extensionName(id)
+	if name == "" {
+		name = id
+	}
+	if critical {
+		w.line(indent, "%s: critical", name)
+	} else {
+		w.line(indent, "%s:", name)
+	}
+
+	indent++
+	switch id {
+	case oidExtensionSubjectKeyId:
+		w.line(indent, "%s", formatHex(cert.SubjectKeyId))
+	case oidExtensionAuthorityKeyId:
+		w.line(indent, "keyid:%s", formatHex(cert.AuthorityKeyId))
+	case oidExtensionKeyUsage.String():
+		w.line(indent, "%s", strings.Join(keyUsageNames(cert.KeyUsage), ", "))
+	case oidExtensionExtendedKeyUsage.String():
+		var ekus []string
+		for _, e := range cert.ExtKeyUsage {
+			if value, ok := ekuActionToString[e]; ok {
+				ekus = append(ekus, value)
+			}
+		}
+		for _, oid := range cert.UnknownExtKeyUsage {
+			ekus = append(ekus, oid.String())
+		}
+		w.line(indent, "%s", strings.Join(ekus, ", "))
+	case oidExtensionSubjectAltName:
+		var names []string
+		for _, name := range cert.DNSNames {
+			names = append(names, "DNS:"+name)
+		}
+		for _, ip := range cert.IPAddresses {
+			names = append(names, "IP Address:"+ip.String())
+		}
+		for _, email := range cert.EmailAddresses {
+			names = append(names, "email:"+email)
+		}
+		for _, uri := range cert.URIs {
+			names = append(names, "URI:"+uri.String())
+		}
+		w.line(indent, "%s", strings.Join(names, ", "))
+	case oidExtensionBasicConstraints.String():
+		if !cert.IsCA {
+			w.line(indent, "CA:FALSE")
+		} else if cert.MaxPathLen > 0 || cert.MaxPathLenZero {
+			w.line(indent, "CA:TRUE, pathlen:%d", cert.MaxPathLen)
+		} else {
+			w.line(indent, "CA:TRUE")
+		}
+	case oidExtensionNameConstraints:
+		w.nameConstraints(indent, cert)
+	case oidExtensionCertificatePolicies:
+		w.certificatePolicies(indent, value)
+	case oidExtensionPolicyMappings:
+		var mappings []policyMapping
+		if _, err := asn1.Unmarshal(value, &mappings); err != nil {
+			w.unknown(indent, value)
+			return
+		}
+		for _, m := range mappings {
+			w.line(indent, "%s:%s", m.IssuerDomainPolicy, m.SubjectDomainPolicy)
+		}
+	case oidExtensionPolicyConstraints:
+		var pc policyConstraints
+		if _, err := asn1.Unmarshal(value, &pc); err != nil {
+			w.unknown(indent, value)
+			return
+		}
+		if pc.RequireExplicitPolicy >= 0 {
+			w.line(indent, "Require Explicit Policy:%d", pc.RequireExplicitPolicy)
+		}
+		if pc.InhibitPolicyMapping >= 0 {
+			w.line(indent, "Inhibit Policy Mapping:%d", pc.InhibitPolicyMapping)
+		}
+	case oidExtensionInhibitAnyPolicy:
+		var skipCerts int
+		if _, err := asn1.Unmarshal(value, &skipCerts); err != nil {
+			w.unknown(indent, value)
+			return
+		}
+		w.line(indent, "%d", skipCerts)
+	case oidExtensionCRLDistributionPoints:
+		for _, dp := range cert.CRLDistributionPoints {
+			w.line(indent, "Full Name:")
+			w.line(indent+1, "URI:%s", dp)
+		}
+	case oidExtensionAuthorityInfoAccess:
+		for _, ocsp := range cert.OCSPServer {
+			w.line(indent, "OCSP - URI:%s", ocsp)
+		}
+		for _, issuer := range cert.IssuingCertificateURL {
+			w.line(indent, "CA Issuers - URI:%s", issuer)
+		}
+	case oidExtensionSCTList:
+		w.sctList(indent, value)
+	default:
+		w.unknown(indent, value)
+	}
+}
+
+func (w *textWriter) unknown(indent int, value []byte) {
+	w.hex(indent, value, 18)
+}
+
+func (w *textWriter) nameConstraints(indent int, cert *x509.Certificate) {
+	var permitted, excluded []string
+	for _, d := range cert.PermittedDNSDomains {
+		permitted = append(permitted, "DNS:"+d)
+	}
+	for _, r := range cert.PermittedIPRanges {
+		permitted = append(permitted, "IP:"+formatIPNet(r))
+	}
+	for _, e := range cert.PermittedEmailAddresses {
+		permitted = append(permitted, "email:"+e)
+	}
+	for _, u := range cert.PermittedURIDomains {
+		permitted = append(permitted, "URI:"+u)
+	}
+	for _, d := range cert.ExcludedDNSDomains {
+		excluded = append(excluded, "DNS:"+d)
+	}
+	for _, r := range cert.ExcludedIPRanges {
+		excluded = append(excluded, "IP:"+formatIPNet(r))
+	}
+	for _, e := range cert.ExcludedEmailAddresses {
+		excluded = append(excluded, "email:"+e)
+	}
+	for _, u := range cert.ExcludedURIDomains {
+		excluded = append(excluded, "URI:"+u)
+	}
+
+	if len(permitted) > 0 {
+		w.line(indent, "Permitted:")
+		for _, p := range permitted {
+			w.line(indent+1, "%s", p)
+		}
+	}
+	if len(excluded) > 0 {
+		w.line(indent, "Excluded:")
+		for _, e := range excluded {
+			w.line(indent+1, "%s", e)
+		}
+	}
+}
+
+func (w *textWriter) certificatePolicies(indent int, value []byte) {
+	var policies []policyInformation
+	if _, err := asn1.Unmarshal(value, &policies); err != nil {
+		w.unknown(indent, value)
+		return
+	}
+
+	for _, policy := range policies {
+		w.line(indent, "Policy: %s", policy.Policy)
+		for _, q := range policy.Qualifiers {
+			switch {
+			case q.PolicyQualifierID.Equal(oidPolicyQualifierCPS):
+				w.line(indent+1, "CPS: %s", string(q.Qualifier.Bytes))
+			case q.PolicyQualifierID.Equal(oidPolicyQualifierUserNotice):
+				w.line(indent+1, "User Notice:")
+				w.userNotice(indent+2, q.Qualifier)
+			default:
+				w.line(indent+1, "%s:", q.PolicyQualifierID)
+				w.unknown(indent+2, q.Qualifier.FullBytes)
+			}
+		}
+	}
+}
+
+// userNotice writes the notice reference and explicit text, both are optional
+func (w *textWriter) userNotice(indent int, notice asn1.RawValue) {
+	rest := notice.Bytes
+	for len(rest) > 0 {
+		var v asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &v)
+		if err != nil {
+			return
+		}
+
+		if v.Tag == asn1.TagSequence {
+			var ref struct {
+				Organization  asn1.RawValue
+				NoticeNumbers []int
+			}
+			if _, err := asn1.Unmarshal(v.FullBytes, &ref); err == nil {
+				w.line(indent, "Organization: %s", decodeDisplayText(ref.Organization))
+				w.line(indent, "Number: %s", strings.Trim(fmt.Sprint(ref.NoticeNumbers), "[]"))
+			}
+		} else {
+			w.line(indent, "Explicit Text: %s", decodeDisplayText(v))
+		}
+	}
+}
+
+// sctList writes the signed certificate timestamps, see RFC 6962 section 3.3
+func (w *textWriter) sctList(indent int, value []byte) {
+	var list []byte
+	if _, err := asn1.Unmarshal(value, &list); err != nil || len(list) < 2 {
+		w.unknown(indent, value)
+		return
+	}
+
+	data := list[2:]
+	for len(data) >= 2 {
+		size := int(binary.BigEndian.Uint16(data))
+		if len(data) < 2+size {
+			break
+		}
+		sct := data[2 : 2+size]
+		data = data[2+size:]
+
+		// version(1) + log id(32) + timestamp(8) + extensions length(2)
+		if len(sct) < 43 {
+			continue
+		}
+		w.line(indent, "Signed Certificate Timestamp:")
+		w.line(indent+1, "Version   : v%d (%#x)", sct[0]+1, sct[0])
+		w.line(indent+1, "Log ID    : %s", formatHex(sct[1:33]))
+		timestamp := binary.BigEndian.Uint64(sct[33:41])
+		w.line(indent+1, "Timestamp : %s", time.UnixMilli(int64(timestamp)).UTC().Format(textTimeFormat))
+
+		extLen := int(binary.BigEndian.Uint16(sct[41:43]))
+		rest := sct[43:]
+		if len(rest) < extLen {
+			continue
+		}
+		if extLen == 0 {
+			w.line(indent+1, "Extensions: none")
+		} else {
+			w.line(indent+1, "Extensions: %s", formatHex(rest[:extLen]))
+		}
+		rest = rest[extLen:]
+
+		// hash(1) + signature(1) + signature length(2)
+		if len(rest) < 4 {
+			continue
+		}
+		w.line(indent+1, "Signature : %s-with-%s", sctHashAlgorithms[rest[0]], sctSignatureAlgorithms[rest[1]])
+		sigLen := int(binary.BigEndian.Uint16(rest[2:4]))
+		if len(rest) >= 4+sigLen {
+			w.hex(indent+2, rest[4:4+sigLen], 16)
+		}
+	}
+}
+
+func decodeDisplayText(v asn1.RawValue) string {
+	if v.Tag == asn1.TagBMPString {
+		return decodeBMPString(v.Bytes)
+	}
+	return string(v.Bytes)
+}
+
+func formatIPNet(n *net.IPNet) string {
+	return fmt.Sprintf("%s/%s", n.IP, net.IP(n.Mask))
+}
+
+// formatHex formats the bytes as upper case hex separated by colon
+func formatHex(b []byte) string {
+	s := strings.ToUpper(hex.EncodeToString(b))
+
+	var parts []string
+	for i := 0; i < len(s); i += 2 {
+		parts = append(parts, s[i:i+2])
+	}
+
+	return strings.Join(parts, ":")
+}
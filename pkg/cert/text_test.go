@@ -0,0 +1,132 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestSCTList returns the ASN.1 OCTET STRING wrapped TLS encoded SCT list
+func newTestSCTList(timestamp uint64, sigs ...[]byte) []byte {
+	var list []byte
+	for i, sig := range sigs {
+		sct := []byte{0}
+		for j := 0; j < 32; j++ {
+			sct = append(sct, byte(i*32+j))
+		}
+		sct = binary.BigEndian.AppendUint64(sct, timestamp)
+		sct = binary.BigEndian.AppendUint16(sct, 0)
+		sct = append(sct, 4, 3)
+		sct = binary.BigEndian.AppendUint16(sct, uint16(len(sig)))
+		sct = append(sct, sig...)
+
+		list = binary.BigEndian.AppendUint16(list, uint16(len(sct)))
+		list = append(list, sct...)
+	}
+
+	value, _ := asn1.Marshal(append(binary.BigEndian.AppendUint16(nil, uint16(len(list))), list...))
+	return value
+}
+
+func TestGetCertText(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(0x1234),
+		Subject:               pkix.Name{CommonName: "china.com", Organization: []string{"China Inc"}},
+		NotBefore:             time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		NotAfter:              time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLen:            1,
+		DNSNames:              []string{"china.com"},
+		PermittedDNSDomains:   []string{".china.com"},
+		ExcludedDNSDomains:    []string{"bad.china.com"},
+		CRLDistributionPoints: []string{"http://crl.china.com/ca.crl"},
+		OCSPServer:            []string{"http://ocsp.china.com"},
+		IssuingCertificateURL: []string{"http://ca.china.com/ca.crt"},
+		PolicyIdentifiers:     []asn1.ObjectIdentifier{{2, 23, 140, 1, 2, 1}},
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Value: []byte{0x04, 0x02, 0xab, 0xcd}},
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}, Value: newTestSCTList(1552524649203, make([]byte, 70))},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("failed CreateCertificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed ParseCertificate: %v", err)
+	}
+
+	text := GetCertText(cert)
+
+	expects := []string{
+		"Version: 3 (0x2)",
+		"12:34",
+		"Not Before: Jan  2 03:04:05 2024 UTC",
+		"Not After : Jan  2 03:04:05 2025 UTC",
+		"Subject: CN=china.com,O=China Inc",
+		"Public Key Algorithm: ECDSA",
+		"Public-Key: (256 bit)",
+		"NIST CURVE: P-256",
+		"Key Usage: critical",
+		"Certificate Sign, Digital Signature",
+		"TLS Web Server Authentication",
+		"Basic Constraints: critical",
+		"CA:TRUE, pathlen:1",
+		"DNS:china.com",
+		"Name Constraints:",
+		"Permitted:",
+		"DNS:.china.com",
+		"Excluded:",
+		"DNS:bad.china.com",
+		"URI:http://crl.china.com/ca.crl",
+		"OCSP - URI:http://ocsp.china.com",
+		"CA Issuers - URI:http://ca.china.com/ca.crt",
+		"Policy: 2.23.140.1.2.1",
+		"1.2.3.4:",
+		"04:02:AB:CD",
+		"Signed Certificate Timestamp:",
+		"Log ID    : 00:01:02:03",
+		"Timestamp : Mar 14 00:50:49 2019 UTC",
+		"Signature : sha256-with-ecdsa",
+		"Signature Algorithm: ECDSA-SHA256",
+		"Signature Value:",
+	}
+	for _, expect := range expects {
+		if !strings.Contains(text, expect) {
+			t.Errorf("failed GetCertText:\n\tactual: %s\n\texpect: %v\n", text, expect)
+		}
+	}
+}
+
+func TestFormatHex(t *testing.T) {
+	var tests = []struct {
+		b      []byte
+		expect string
+	}{
+		{b: []byte{0x01, 0xab, 0xff}, expect: "01:AB:FF"},
+		{b: []byte{0x00}, expect: "00"},
+	}
+
+	for _, test := range tests {
+		if actual := formatHex(test.b); actual != test.expect {
+			t.Errorf("failed formatHex:\n\tactual: %s\n\texpect: %s\n", actual, test.expect)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VerifyOptions are the trust anchors and intermediates used to build the
+// chains of certificate, the zero Purpose is ExtKeyUsageAny and the zero
+// CurrentTime is now. If IgnoreValidity is true and the certificate is
+// expired or not yet valid at CurrentTime, the chains are built at the middle
+// of its validity period, the validity is expected to be checked separately.
+type VerifyOptions struct {
+	Roots          []*x509.Certificate
+	Intermediates  []*x509.Certificate
+	SystemRoots    bool
+	DNSName        string
+	Purpose        x509.ExtKeyUsage
+	CurrentTime    time.Time
+	IgnoreValidity bool
+}
+
+// CSRCheck is the result of checking certificate request, and matching it
+// with the private key and the certificate
+type CSRCheck struct {
+	Signature *VerifyCheck `json:"signature" yaml:"signature"`
+	Key       *VerifyCheck `json:"key,omitempty" yaml:"key,omitempty"`
+	PublicKey *VerifyCheck `json:"publicKey,omitempty" yaml:"publicKey,omitempty"`
+	Subject   *VerifyCheck `json:"subject,omitempty" yaml:"subject,omitempty"`
+	SANs      *VerifyCheck `json:"sans,omitempty" yaml:"sans,omitempty"`
+}
+
+// CertExpiry is the validity of certificate at the verification time, the
+// ExpiresIn is negative if expired
+type CertExpiry struct {
+	Subject   string    `json:"subject" yaml:"subject"`
+	NotBefore time.Time `json:"notBefore" yaml:"notBefore"`
+	NotAfter  time.Time `json:"notAfter" yaml:"notAfter"`
+	Valid     bool      `json:"valid" yaml:"valid"`
+	ExpiresIn string    `json:"expiresIn" yaml:"expiresIn"`
+}
+
+// VerifyChains verifies the certificate against the roots, and the system
+// roots if enabled, and returns every valid chain from the leaf to a root
+func VerifyChains(cert *x509.Certificate, opts *VerifyOptions) ([][]*x509.Certificate, error) {
+	roots := x509.NewCertPool()
+	if opts.SystemRoots {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load system roots: %w", err)
+		}
+		roots = pool
+	}
+	for _, root := range opts.Roots {
+		roots.AddCert(root)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range opts.Intermediates {
+		intermediates.AddCert(intermediate)
+	}
+
+	verifyOpts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       opts.DNSName,
+		KeyUsages:     []x509.ExtKeyUsage{opts.Purpose},
+		CurrentTime:   opts.CurrentTime,
+	}
+	chains, err := cert.Verify(verifyOpts)
+
+	var invalidErr x509.CertificateInvalidError
+	if err != nil && opts.IgnoreValidity && errors.As(err, &invalidErr) && invalidErr.Reason == x509.Expired {
+		verifyOpts.CurrentTime = cert.NotBefore.Add(cert.NotAfter.Sub(cert.NotBefore) / 2)
+		chains, err = cert.Verify(verifyOpts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to verify certificate: %w", err)
+	}
+
+	return chains, nil
+}
+
+// NewVerifiedChains returns the chain info of each verified chain
+func NewVerifiedChains(chains [][]*x509.Certificate) []*ChainInfo {
+	var infos []*ChainInfo
+	for _, chain := range chains {
+		infos = append(infos, NewChainInfo(chain))
+	}
+
+	return infos
+}
+
+// GetVerifiedChainsText returns every verified chain as an indented tree
+func GetVerifiedChainsText(infos []*ChainInfo) string {
+	var b strings.Builder
+
+	for i, info := range infos {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Chain %d:\n", i+1)
+		b.WriteString(GetChainText(info))
+	}
+
+	return b.String()
+}
+
+// GetChainsCerts returns the unique certificates of the chains, in the order
+// of the first appearance
+func GetChainsCerts(chains [][]*x509.Certificate) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for _, chain := range chains {
+		for _, cert := range chain {
+			found := false
+			for _, c := range certs {
+				if bytes.Equal(c.Raw, cert.Raw) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				certs = append(certs, cert)
+			}
+		}
+	}
+
+	return certs
+}
+
+// NewCertExpiries returns the validity of each certificate at the time
+func NewCertExpiries(certs []*x509.Certificate, at time.Time) []*CertExpiry {
+	var expiries []*CertExpiry
+	for _, cert := range certs {
+		expiries = append(expiries, &CertExpiry{
+			Subject:   cert.Subject.String(),
+			NotBefore: cert.NotBefore,
+			NotAfter:  cert.NotAfter,
+			Valid:     !at.Before(cert.NotBefore) && !at.After(cert.NotAfter),
+			ExpiresIn: formatDuration(cert.NotAfter.Sub(at)),
+		})
+	}
+
+	return expiries
+}
+
+// CheckExpiries returns the summary of the certificate which expires first if
+// all certificates are valid, otherwise the error lists the invalid ones
+func CheckExpiries(expiries []*CertExpiry, at time.Time) (string, error) {
+	var invalid []string
+	var first *CertExpiry
+	for _, e := range expiries {
+		switch {
+		case at.Before(e.NotBefore):
+			invalid = append(invalid, fmt.Sprintf("%s is not yet valid until %s", e.Subject, e.NotBefore.UTC().Format(time.RFC3339)))
+		case !e.Valid:
+			invalid = append(invalid, fmt.Sprintf("%s expired %s ago", e.Subject, strings.TrimPrefix(e.ExpiresIn, "-")))
+		case first == nil || e.NotAfter.Before(first.NotAfter):
+			first = e
+		}
+	}
+	if len(invalid) > 0 {
+		return "", fmt.Errorf("%s", strings.Join(invalid, ", "))
+	}
+	if first == nil {
+		return "", nil
+	}
+
+	return fmt.Sprintf("%d certificate(s) valid at %s, %s expires first in %s",
+		len(expiries), at.UTC().Format(time.RFC3339), first.Subject, first.ExpiresIn), nil
+}
+
+// GetExpiryText returns the validity of each certificate at the time
+func GetExpiryText(expiries []*CertExpiry, at time.Time) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Validity at %s:\n", at.UTC().Format(time.RFC3339))
+	for _, e := range expiries {
+		switch {
+		case at.Before(e.NotBefore):
+			fmt.Fprintf(&b, "%s: not yet valid, valid from %s\n", e.Subject, e.NotBefore.UTC().Format(time.RFC3339))
+		case !e.Valid:
+			fmt.Fprintf(&b, "%s: expired %s ago\n", e.Subject, strings.TrimPrefix(e.ExpiresIn, "-"))
+		default:
+			fmt.Fprintf(&b, "%s: expires in %s\n", e.Subject, e.ExpiresIn)
+		}
+	}
+
+	return b.String()
+}
+
+// ParseTime parses the time in RFC 3339 format or the date like 2006-01-02
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("Invalid time %s, expect RFC 3339 like 2027-01-01T00:00:00Z or date like 2027-01-01", s)
+}
+
+// ParseDuration parses the duration in days like 30d, or the Go duration
+// like 12h
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+
+	return 0, fmt.Errorf("Invalid duration %s, expect days like 30d or Go duration like 12h", s)
+}
+
+// CheckCertificateRequest checks the signature of certificate request, and
+// if cert is not nil, the certificate has the requested public key, subject
+// and SANs
+func CheckCertificateRequest(csr *x509.CertificateRequest, cert *x509.Certificate) *CSRCheck {
+	check := &CSRCheck{Signature: NewVerifyCheck(csr.CheckSignature())}
+	if cert == nil {
+		return check
+	}
+
+	var err error
+	if !PublicKeyEqual(cert.PublicKey, csr.PublicKey) {
+		err = fmt.Errorf("the certificate public key does not match the CSR")
+	}
+	check.PublicKey = NewVerifyCheck(err)
+
+	err = nil
+	if !bytes.Equal(cert.RawSubject, csr.RawSubject) && cert.Subject.String() != csr.Subject.String() {
+		err = fmt.Errorf("the certificate subject %s differs from the requested %s", cert.Subject.String(), csr.Subject.String())
+	}
+	check.Subject = NewVerifyCheck(err)
+
+	check.SANs = NewVerifyCheck(matchSANs(
+		newSubjectAltNames(csr.DNSNames, csr.IPAddresses, csr.EmailAddresses, csr.URIs).All(),
+		newSubjectAltNames(cert.DNSNames, cert.IPAddresses, cert.EmailAddresses, cert.URIs).All(),
+	))
+
+	return check
+}
+
+// matchSANs returns error with the missing and extra SANs of the certificate
+func matchSANs(requested, actual []string) error {
+	// DNS names are case-insensitive
+	contains := func(sans []string, san string) bool {
+		for _, s := range sans {
+			if strings.EqualFold(s, san) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var missing, extra []string
+	for _, san := range requested {
+		if !contains(actual, san) {
+			missing = append(missing, san)
+		}
+	}
+	for _, san := range actual {
+		if !contains(requested, san) {
+			extra = append(extra, san)
+		}
+	}
+	if len(missing) == 0 && len(extra) == 0 {
+		return nil
+	}
+
+	var diffs []string
+	if len(missing) > 0 {
+		diffs = append(diffs, "missing "+strings.Join(missing, ", "))
+	}
+	if len(extra) > 0 {
+		diffs = append(diffs, "extra "+strings.Join(extra, ", "))
+	}
+
+	return fmt.Errorf("the certificate SANs differ from the requested: %s", strings.Join(diffs, "; "))
+}
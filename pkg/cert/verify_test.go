@@ -0,0 +1,249 @@
+package cert
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyChains(t *testing.T) {
+	certs := newTestChain(t)
+	root, inter, leaf := certs[0], certs[1], certs[2]
+
+	var tests = []struct {
+		opts   *VerifyOptions
+		chains int
+		length int
+		errMsg string
+	}{
+		{
+			opts:   &VerifyOptions{Roots: []*x509.Certificate{root}},
+			errMsg: "unknown authority",
+		},
+		{
+			opts:   &VerifyOptions{Roots: []*x509.Certificate{root}, Intermediates: []*x509.Certificate{inter}},
+			chains: 1,
+			length: 3,
+		},
+		{
+			opts:   &VerifyOptions{Roots: []*x509.Certificate{inter}},
+			chains: 1,
+			length: 2,
+		},
+		{
+			opts:   &VerifyOptions{Roots: []*x509.Certificate{root, inter}, Intermediates: []*x509.Certificate{inter}},
+			chains: 2,
+			length: 2,
+		},
+		{
+			opts:   &VerifyOptions{Roots: []*x509.Certificate{root}, Intermediates: []*x509.Certificate{inter}, DNSName: "www.leaf.com"},
+			errMsg: "certificate is not valid for any names",
+		},
+		{
+			opts:   &VerifyOptions{Roots: []*x509.Certificate{root}, Intermediates: []*x509.Certificate{inter}, CurrentTime: time.Now().Add(2 * time.Hour)},
+			errMsg: "certificate has expired or is not yet valid",
+		},
+		{
+			opts:   &VerifyOptions{Roots: []*x509.Certificate{root}, Intermediates: []*x509.Certificate{inter}, CurrentTime: time.Now().Add(2 * time.Hour), IgnoreValidity: true},
+			chains: 1,
+			length: 3,
+		},
+	}
+
+	for i, test := range tests {
+		chains, err := VerifyChains(leaf, test.opts)
+		if test.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), test.errMsg) {
+				t.Errorf("failed VerifyChains %d:\n\tactual: %v\n\texpect: %s\n", i, err, test.errMsg)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("failed VerifyChains %d: %v", i, err)
+			continue
+		}
+		if len(chains) != test.chains || len(chains[0]) != test.length {
+			t.Errorf("failed VerifyChains %d:\n\tactual: %d chains, %d certs\n\texpect: %d chains, %d certs\n", i, len(chains), len(chains[0]), test.chains, test.length)
+		}
+	}
+}
+
+func TestGetVerifiedChainsText(t *testing.T) {
+	certs := newTestChain(t)
+	root, inter, leaf := certs[0], certs[1], certs[2]
+
+	chains, err := VerifyChains(leaf, &VerifyOptions{Roots: []*x509.Certificate{root}, Intermediates: []*x509.Certificate{inter}})
+	if err != nil {
+		t.Fatalf("failed VerifyChains: %v", err)
+	}
+
+	expect := "Chain 1:\n" +
+		"[1] CN=leaf.com\n" +
+		"└── [2] CN=Inter CA\n" +
+		"    └── [3] CN=Root CA (root)\n"
+	if actual := GetVerifiedChainsText(NewVerifiedChains(chains)); actual != expect {
+		t.Errorf("failed GetVerifiedChainsText:\n\tactual: %s\n\texpect: %s\n", actual, expect)
+	}
+}
+
+func TestGetChainsCerts(t *testing.T) {
+	certs := newTestChain(t)
+	root, inter, leaf := certs[0], certs[1], certs[2]
+
+	actual := GetChainsCerts([][]*x509.Certificate{{leaf, inter}, {leaf, inter, root}})
+	if len(actual) != 3 || actual[0] != leaf || actual[1] != inter || actual[2] != root {
+		t.Errorf("failed GetChainsCerts: %v", actual)
+	}
+}
+
+func TestNewCertExpiries(t *testing.T) {
+	certs := newTestChain(t)
+	leaf := certs[2]
+
+	var tests = []struct {
+		at     time.Time
+		valid  bool
+		expect string
+	}{
+		{at: leaf.NotAfter.Add(-30 * time.Minute), valid: true, expect: "CN=leaf.com: expires in 30m\n"},
+		{at: leaf.NotAfter.Add(49 * time.Hour), valid: false, expect: "CN=leaf.com: expired 2d 1h 0m ago\n"},
+		{at: leaf.NotBefore.Add(-time.Hour), valid: false, expect: "CN=leaf.com: not yet valid, valid from "},
+	}
+
+	for _, test := range tests {
+		expiries := NewCertExpiries([]*x509.Certificate{leaf}, test.at)
+		if len(expiries) != 1 || expiries[0].Valid != test.valid {
+			t.Errorf("failed NewCertExpiries at %s:\n\tactual: %v\n\texpect: %v\n", test.at, expiries[0].Valid, test.valid)
+		}
+
+		text := GetExpiryText(expiries, test.at)
+		if !strings.Contains(text, test.expect) {
+			t.Errorf("failed GetExpiryText:\n\tactual: %s\n\texpect: %s\n", text, test.expect)
+		}
+	}
+}
+
+func TestCheckExpiries(t *testing.T) {
+	certs := newTestChain(t)
+	leaf := certs[2]
+
+	var tests = []struct {
+		at     time.Time
+		expect string
+		errMsg string
+	}{
+		{at: leaf.NotAfter.Add(-30 * time.Minute), expect: "3 certificate(s) valid at "},
+		{at: leaf.NotAfter.Add(-30 * time.Minute), expect: " expires first in "},
+		{at: leaf.NotAfter.Add(49 * time.Hour), errMsg: "CN=leaf.com expired 2d 1h 0m ago"},
+		{at: leaf.NotBefore.Add(-time.Hour), errMsg: "CN=leaf.com is not yet valid until "},
+	}
+
+	for _, test := range tests {
+		summary, err := CheckExpiries(NewCertExpiries(certs, test.at), test.at)
+		if test.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), test.errMsg) {
+				t.Errorf("failed CheckExpiries at %s:\n\tactual: %v\n\texpect: %s\n", test.at, err, test.errMsg)
+			}
+			continue
+		}
+		if err != nil || !strings.Contains(summary, test.expect) {
+			t.Errorf("failed CheckExpiries at %s:\n\tactual: %s %v\n\texpect: %s\n", test.at, summary, err, test.expect)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	var tests = []struct {
+		s      string
+		expect time.Time
+		err    bool
+	}{
+		{s: "2027-01-01T00:00:00Z", expect: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{s: "2027-01-01T08:00:00+08:00", expect: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{s: "2027-01-01", expect: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{s: "01/01/2027", err: true},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseTime(test.s)
+		if (err != nil) != test.err || !actual.Equal(test.expect) {
+			t.Errorf("failed ParseTime %s:\n\tactual: %v %v\n\texpect: %v\n", test.s, actual, err, test.expect)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	var tests = []struct {
+		s      string
+		expect time.Duration
+		err    bool
+	}{
+		{s: "30d", expect: 30 * 24 * time.Hour},
+		{s: "12h", expect: 12 * time.Hour},
+		{s: "1h30m", expect: 90 * time.Minute},
+		{s: "d", err: true},
+		{s: "3x", err: true},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseDuration(test.s)
+		if (err != nil) != test.err || actual != test.expect {
+			t.Errorf("failed ParseDuration %s:\n\tactual: %v %v\n\texpect: %v\n", test.s, actual, err, test.expect)
+		}
+	}
+}
+
+func TestCheckCertificateRequest(t *testing.T) {
+	keyAlg, _ := NewKeyAlgorithm(KeyTypeECDSA, 0, "P-256")
+	key, err := keyAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed GenerateKey: %v", err)
+	}
+	otherKey, _ := keyAlg.GenerateKey()
+
+	reqInfo, _ := NewCertInfo(time.Hour, "CN=china.com/O=China Inc", "china.com,www.china.com,10.0.0.1", "", "", false)
+	csrBytes, err := NewCertRequest(reqInfo, key)
+	if err != nil {
+		t.Fatalf("failed NewCertRequest: %v", err)
+	}
+	csr, err := ParseCertRequest(csrBytes)
+	if err != nil {
+		t.Fatalf("failed ParseCertRequest: %v", err)
+	}
+
+	var tests = []struct {
+		subject  string
+		san      string
+		otherKey bool
+		errMsgs  [3]string
+	}{
+		{subject: "CN=china.com/O=China Inc", san: "WWW.china.com,10.0.0.1,china.com"},
+		{subject: "CN=china.com/O=China Inc", san: "china.com,10.0.0.2", errMsgs: [3]string{"", "", "missing www.china.com, 10.0.0.1; extra 10.0.0.2"}},
+		{subject: "CN=china.com", san: "china.com,www.china.com,10.0.0.1", errMsgs: [3]string{"", "the certificate subject CN=china.com differs from the requested CN=china.com,O=China Inc", ""}},
+		{subject: "CN=china.com/O=China Inc", san: "china.com,www.china.com,10.0.0.1", otherKey: true, errMsgs: [3]string{"does not match the CSR", "", ""}},
+	}
+
+	for _, test := range tests {
+		certKey := key
+		if test.otherKey {
+			certKey = otherKey
+		}
+		cert, _ := newTestCert(t, nil, nil, testCertOptions{subject: test.subject, san: test.san, key: certKey})
+
+		check := CheckCertificateRequest(csr, cert)
+		if !check.Signature.Verified {
+			t.Errorf("failed CheckCertificateRequest signature: %s", check.Signature.Error)
+		}
+		for i, c := range []*VerifyCheck{check.PublicKey, check.Subject, check.SANs} {
+			if c.Verified != (test.errMsgs[i] == "") || !strings.Contains(c.Error, test.errMsgs[i]) {
+				t.Errorf("failed CheckCertificateRequest %s %s:\n\tactual: %s\n\texpect: %s\n", test.subject, test.san, c.Error, test.errMsgs[i])
+			}
+		}
+	}
+
+	check := CheckCertificateRequest(csr, nil)
+	if !check.Signature.Verified || check.PublicKey != nil || check.Subject != nil || check.SANs != nil {
+		t.Errorf("failed CheckCertificateRequest without certificate: %+v", check)
+	}
+}